@@ -36,3 +36,8 @@ func (list ErrorList) Error() string {
 
 	return strings.Join(acc, ". ")
 }
+
+// Unwrap returns the errors in the list so they can be inspected with errors.Is and errors.As
+func (list ErrorList) Unwrap() []error {
+	return list
+}
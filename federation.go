@@ -0,0 +1,153 @@
+package graphql
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+	"github.com/vektah/gqlparser/v2/validator"
+)
+
+// federationSDLQuery asks an Apollo Federation subgraph for its schema
+const federationSDLQuery = `query FederationSDL { _service { sdl } }`
+
+// federationPrelude defines the federation directives and scalars that a subgraph's SDL uses
+// without defining them
+var federationPrelude = &ast.Source{
+	Name:    "federation.graphql",
+	BuiltIn: true,
+	Input: `
+scalar _Any
+scalar _FieldSet
+scalar FieldSet
+scalar link__Import
+
+enum link__Purpose {
+	SECURITY
+	EXECUTION
+}
+
+directive @key(fields: _FieldSet!, resolvable: Boolean = true) repeatable on OBJECT | INTERFACE
+directive @external(reason: String) on OBJECT | FIELD_DEFINITION
+directive @requires(fields: _FieldSet!) on FIELD_DEFINITION
+directive @provides(fields: _FieldSet!) on FIELD_DEFINITION
+directive @extends on OBJECT | INTERFACE
+directive @shareable repeatable on OBJECT | FIELD_DEFINITION
+directive @inaccessible on FIELD_DEFINITION | OBJECT | INTERFACE | UNION | ARGUMENT_DEFINITION | SCALAR | ENUM | ENUM_VALUE | INPUT_OBJECT | INPUT_FIELD_DEFINITION
+directive @override(from: String!, label: String) on FIELD_DEFINITION
+directive @tag(name: String!) repeatable on FIELD_DEFINITION | OBJECT | INTERFACE | UNION | ARGUMENT_DEFINITION | SCALAR | ENUM | ENUM_VALUE | INPUT_OBJECT | INPUT_FIELD_DEFINITION
+directive @interfaceObject on OBJECT
+directive @composeDirective(name: String!) repeatable on SCHEMA
+directive @link(url: String!, as: String, import: [link__Import], for: link__Purpose) repeatable on SCHEMA
+`,
+}
+
+// IntrospectWithFederationSDL returns an instance of graphql.IntrospectOptions that fetches the schema of an
+// Apollo Federation subgraph from its _service { sdl } field instead of the introspection query. This keeps the
+// federation directives, like @key and @external, that introspection does not expose. Services without the
+// _service field are introspected as usual.
+func IntrospectWithFederationSDL() *IntrospectOptions {
+	return introspectOptsFunc(func(opts *IntrospectOptions) {
+		opts.federationSDL = true
+	})
+}
+
+// FederationSDLAPI fetches the schema of an Apollo Federation subgraph from its _service { sdl } field
+// and falls back to the introspection query when the field is not available
+func FederationSDLAPI(queryer Queryer, opts ...*IntrospectOptions) (*ast.Schema, error) {
+	opt := mergeIntrospectOptions(opts...)
+	return federationSDLAPI(opt.Apply(queryer), opt)
+}
+
+func federationSDLAPI(queryer Queryer, opt *IntrospectOptions) (*ast.Schema, error) {
+	var result struct {
+		Service *struct {
+			SDL string `json:"sdl"`
+		} `json:"_service"`
+	}
+	unsupported := false
+	err := opt.retry(func() error {
+		result.Service = nil
+		err := queryer.Query(opt.Context(), &QueryInput{
+			Query:         federationSDLQuery,
+			OperationName: "FederationSDL",
+		}, &result)
+
+		// a service that isn't a subgraph won't get any better by asking again
+		unsupported = federationSDLUnsupported(err)
+		if unsupported {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return nil, errors.WithMessage(err, "fetching federation SDL")
+	}
+
+	// services that aren't subgraphs reject the query, so introspect them instead
+	if unsupported || result.Service == nil || result.Service.SDL == "" {
+		return introspectAPI(queryer, opt)
+	}
+
+	schema, err := LoadFederationSchema(result.Service.SDL)
+	return schema, errors.WithMessage(err, "invalid federation SDL")
+}
+
+// federationSDLUnsupported returns true if the error is a service rejecting the _service field because it
+// doesn't have one, as opposed to a failure that would hide the actual problem behind an introspection error
+func federationSDLUnsupported(err error) bool {
+	var errs ErrorList
+	if !errors.As(err, &errs) {
+		var graphqlErr *Error
+		if !errors.As(err, &graphqlErr) {
+			return false
+		}
+		errs = ErrorList{graphqlErr}
+	}
+
+	for _, err := range errs {
+		graphqlErr, ok := err.(*Error)
+		if !ok {
+			continue
+		}
+		if strings.Contains(graphqlErr.Message, "Cannot query field") && strings.Contains(graphqlErr.Message, "_service") {
+			return true
+		}
+		if code, _ := graphqlErr.Extensions["code"].(string); code == "GRAPHQL_VALIDATION_FAILED" {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadFederationSchema parses the SDL of an Apollo Federation subgraph. The federation directives and
+// scalars it uses are defined as built-ins unless the SDL defines them itself.
+func LoadFederationSchema(sdl string) (*ast.Schema, error) {
+	source := &ast.Source{Name: "_service.sdl", Input: sdl}
+	document, err := parser.ParseSchema(source)
+	if err != nil {
+		return nil, ErrorList{newSchemaError(source.Name, err)}
+	}
+
+	builtins, err := parser.ParseSchemas(validator.Prelude, federationPrelude)
+	if err != nil {
+		return nil, err
+	}
+
+	// only add the built-in definitions that the SDL leaves out
+	combined := &ast.SchemaDocument{}
+	for _, definition := range builtins.Definitions {
+		if document.Definitions.ForName(definition.Name) == nil {
+			combined.Definitions = append(combined.Definitions, definition)
+		}
+	}
+	for _, directive := range builtins.Directives {
+		if document.Directives.ForName(directive.Name) == nil {
+			combined.Directives = append(combined.Directives, directive)
+		}
+	}
+	combined.Merge(document)
+
+	return validateSchemaDocument(combined)
+}
@@ -0,0 +1,180 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const federationTestSDL = `
+	extend schema @link(url: "https://specs.apollo.dev/federation/v2.3", import: ["@key", "@external", "@requires", "@provides"])
+
+	type Query {
+		me: User
+	}
+
+	type User @key(fields: "id") {
+		id: ID!
+		name: String @external
+		greeting: String @requires(fields: "name")
+		reviews: [Review] @provides(fields: "author { id }")
+	}
+
+	type Review @key(fields: "id") @key(fields: "slug", resolvable: false) {
+		id: ID!
+		slug: String!
+		author: User
+	}
+`
+
+func TestLoadFederationSchema(t *testing.T) {
+	t.Parallel()
+	schema, err := LoadFederationSchema(federationTestSDL)
+	require.NoError(t, err)
+
+	user := schema.Types["User"]
+	require.NotNil(t, user)
+	key := user.Directives.ForName("key")
+	require.NotNil(t, key)
+	assert.Equal(t, "id", key.Arguments.ForName("fields").Value.Raw)
+	assert.NotNil(t, key.Definition)
+	assert.NotNil(t, user.Fields.ForName("name").Directives.ForName("external"))
+	assert.NotNil(t, user.Fields.ForName("greeting").Directives.ForName("requires"))
+	assert.NotNil(t, user.Fields.ForName("reviews").Directives.ForName("provides"))
+	assert.Len(t, schema.Types["Review"].Directives.ForNames("key"), 2)
+
+	// the federation definitions are built in, so they don't show up in the printed schema
+	assert.True(t, schema.Types["_FieldSet"].BuiltIn)
+	printed, err := PrintSchema(schema)
+	require.NoError(t, err)
+	assert.NotContains(t, printed, "directive @key")
+	assert.Contains(t, printed, `type User @key(fields: "id")`)
+}
+
+func TestLoadFederationSchema_federationV1(t *testing.T) {
+	t.Parallel()
+	// federation 1 subgraphs extend types they don't own and can define the directives themselves
+	schema, err := LoadFederationSchema(`
+		scalar _FieldSet
+		directive @key(fields: _FieldSet!) on OBJECT | INTERFACE
+
+		extend type Query {
+			topProducts: [Product]
+		}
+
+		type Product @key(fields: "upc") {
+			upc: String!
+		}
+
+		extend type User @key(fields: "id") {
+			id: ID! @external
+		}
+	`)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Query", schema.Query.Name)
+	assert.NotNil(t, schema.Types["User"].Fields.ForName("id").Directives.ForName("external"))
+	assert.False(t, schema.Types["_FieldSet"].BuiltIn)
+	assert.Len(t, schema.Directives["key"].Arguments, 1)
+}
+
+func TestLoadFederationSchema_invalid(t *testing.T) {
+	t.Parallel()
+	_, err := LoadFederationSchema("type Query {\n  me: Missing\n}")
+	var schemaErr *SchemaError
+	require.ErrorAs(t, err, &schemaErr)
+	assert.Equal(t, 2, schemaErr.Line)
+}
+
+func TestIntrospectAPI_federationSDL(t *testing.T) {
+	t.Parallel()
+	sdl, err := json.Marshal(federationTestSDL)
+	require.NoError(t, err)
+	queryer := &mockOperationQueryer{
+		JSONResults: map[string]string{
+			"FederationSDL": `{"_service": {"sdl": ` + string(sdl) + `}}`,
+		},
+	}
+
+	schema, err := IntrospectAPI(queryer, IntrospectWithFederationSDL())
+	require.NoError(t, err)
+	assert.Equal(t, []string{federationSDLQuery}, queryer.Queries)
+	assert.NotNil(t, schema.Types["User"].Directives.ForName("key"))
+}
+
+func TestIntrospectAPI_federationSDLFallback(t *testing.T) {
+	t.Parallel()
+	schema, err := LoadSchema(`type Query { hello: String }`)
+	require.NoError(t, err)
+	introspection := NewSchemaIntrospectionQueryer(schema)
+
+	operations := []string{}
+	queryer := QueryerFunc(func(input *QueryInput) (interface{}, error) {
+		operations = append(operations, input.OperationName)
+		if input.OperationName == "FederationSDL" {
+			return nil, ErrorList{&Error{Message: `Cannot query field "_service" on type "Query".`}}
+		}
+		var result IntrospectionQueryResult
+		err := introspection.Query(context.Background(), input, &result)
+		return result, err
+	})
+
+	introspected, err := FederationSDLAPI(queryer)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"FederationSDL", "IntrospectionFeatures", "IntrospectionQuery"}, operations)
+	assert.NotNil(t, introspected.Query.Fields.ForName("hello"))
+}
+
+func TestIntrospectAPI_federationSDLInvalid(t *testing.T) {
+	t.Parallel()
+	queryer := &mockOperationQueryer{
+		JSONResults: map[string]string{
+			"FederationSDL": `{"_service": {"sdl": "type Query {"}}`,
+		},
+	}
+
+	_, err := IntrospectAPI(queryer, IntrospectWithFederationSDL())
+	assert.ErrorContains(t, err, "invalid federation SDL")
+	var schemaErr *SchemaError
+	assert.ErrorAs(t, err, &schemaErr)
+	assert.Equal(t, "_service.sdl", schemaErr.File)
+}
+
+// flakyFederationQueryer fails the first requests before answering with the SDL
+type flakyFederationQueryer struct {
+	failures   int
+	operations []string
+}
+
+func (q *flakyFederationQueryer) Query(ctx context.Context, input *QueryInput, receiver interface{}) error {
+	q.operations = append(q.operations, input.OperationName)
+	if len(q.operations) <= q.failures {
+		return errors.New("response was not successful with status code: 503")
+	}
+	sdl, err := json.Marshal(federationTestSDL)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(`{"_service": {"sdl": `+string(sdl)+`}}`), receiver)
+}
+
+func TestIntrospectAPI_federationSDLFailure(t *testing.T) {
+	t.Parallel()
+
+	// failures that have nothing to do with the service being a subgraph are retried
+	queryer := &flakyFederationQueryer{failures: 1}
+	schema, err := IntrospectAPI(queryer, IntrospectWithFederationSDL(), IntrospectWithRetrier(NewCountRetrier(1)))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"FederationSDL", "FederationSDL"}, queryer.operations)
+	assert.NotNil(t, schema.Types["User"].Directives.ForName("key"))
+
+	// and returned as is instead of falling back to introspection
+	queryer = &flakyFederationQueryer{failures: 1}
+	_, err = IntrospectAPI(queryer, IntrospectWithFederationSDL())
+	assert.EqualError(t, err, "fetching federation SDL: response was not successful with status code: 503")
+	assert.Equal(t, []string{"FederationSDL"}, queryer.operations)
+}
@@ -1,8 +1,17 @@
 package graphql
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
 	"github.com/vektah/gqlparser/v2"
 	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"github.com/vektah/gqlparser/v2/parser"
+	"github.com/vektah/gqlparser/v2/validator"
 )
 
 // LoadSchema takes an SDL string and returns the parsed version
@@ -17,3 +26,140 @@ func LoadSchema(typedef string) (*ast.Schema, error) {
 	}
 	return schema, nil
 }
+
+// SchemaError describes a problem at a specific location of a schema source
+type SchemaError struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Message)
+}
+
+// LoadSchemaSources parses and validates a schema split across several sources, applying the
+// type extensions in one source to the definitions in another. Problems are returned as an ErrorList
+// of *SchemaError. Syntax errors and redeclared types and directives are reported for every source,
+// while the remaining validation stops at the first problem found.
+func LoadSchemaSources(sources ...*ast.Source) (*ast.Schema, error) {
+	document := &ast.SchemaDocument{}
+	errList := ErrorList{}
+
+	for _, source := range append([]*ast.Source{validator.Prelude}, sources...) {
+		sourceDocument, err := parser.ParseSchema(source)
+		if err != nil {
+			errList = append(errList, newSchemaError(source.Name, err))
+			continue
+		}
+		document.Merge(sourceDocument)
+	}
+	if len(errList) > 0 {
+		return nil, errList
+	}
+
+	return validateSchemaDocument(document)
+}
+
+// validateSchemaDocument builds the schema described by a document that already includes the prelude
+func validateSchemaDocument(document *ast.SchemaDocument) (*ast.Schema, error) {
+	// the validator stops at the first redeclaration, so look for all of them here
+	if errList := schemaRedeclarationErrors(document); len(errList) > 0 {
+		return nil, ErrorList(errList)
+	}
+
+	schema, err := validator.ValidateSchemaDocument(document)
+	if err != nil {
+		return nil, ErrorList{newSchemaError("", err)}
+	}
+	return schema, nil
+}
+
+// LoadSchemaFiles reads the SDL files at the given paths and loads them with LoadSchemaSources
+func LoadSchemaFiles(paths ...string) (*ast.Schema, error) {
+	sources := []*ast.Source{}
+	for _, path := range paths {
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, &ast.Source{Name: path, Input: string(contents)})
+	}
+
+	return LoadSchemaSources(sources...)
+}
+
+// LoadSchemaGlob loads every SDL file matching the pattern, e.g. "schema/*.graphql", in lexical order
+// with LoadSchemaSources. See filepath.Match for the pattern syntax.
+func LoadSchemaGlob(pattern string) (*ast.Schema, error) {
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no schema files match %s", pattern)
+	}
+	sort.Strings(paths)
+
+	return LoadSchemaFiles(paths...)
+}
+
+// newSchemaError converts an error from gqlparser into a *SchemaError
+func newSchemaError(file string, err error) *SchemaError {
+	var gqlErr *gqlerror.Error
+	if !errors.As(err, &gqlErr) {
+		return &SchemaError{File: file, Message: err.Error()}
+	}
+
+	schemaErr := &SchemaError{File: file, Message: gqlErr.Message}
+	if name, ok := gqlErr.Extensions["file"].(string); ok {
+		schemaErr.File = name
+	}
+	if len(gqlErr.Locations) > 0 {
+		schemaErr.Line = gqlErr.Locations[0].Line
+		schemaErr.Column = gqlErr.Locations[0].Column
+	}
+	return schemaErr
+}
+
+// schemaRedeclarationErrors returns an error for every type and directive that is defined more than once
+func schemaRedeclarationErrors(document *ast.SchemaDocument) []error {
+	errs := []error{}
+
+	types := map[string]bool{}
+	for _, definition := range document.Definitions {
+		if types[definition.Name] {
+			errs = append(errs, schemaPositionError(definition.Position, "Cannot redeclare type %s.", definition.Name))
+		}
+		types[definition.Name] = true
+	}
+
+	directives := map[string]bool{}
+	for _, directive := range document.Directives {
+		// like the validator, allow the built-in directives to be defined again
+		if directives[directive.Name] && !isBuiltInDirectiveName(directive.Name) {
+			errs = append(errs, schemaPositionError(directive.Position, "Cannot redeclare directive %s.", directive.Name))
+		}
+		directives[directive.Name] = true
+	}
+
+	return errs
+}
+
+func schemaPositionError(position *ast.Position, format string, args ...interface{}) *SchemaError {
+	return &SchemaError{
+		File:    position.Src.Name,
+		Line:    position.Line,
+		Column:  position.Column,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+func isBuiltInDirectiveName(name string) bool {
+	switch name {
+	case "include", "skip", "deprecated", "specifiedBy", "defer":
+		return true
+	}
+	return false
+}
@@ -1,7 +1,13 @@
 package graphql
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
 )
 
 func TestLoadSchema_succeed(t *testing.T) {
@@ -29,3 +35,108 @@ func TestLoadSchema_fails(t *testing.T) {
 		return
 	}
 }
+
+func TestLoadSchemaSources(t *testing.T) {
+	t.Parallel()
+	schema, err := LoadSchemaSources(
+		&ast.Source{Name: "query.graphql", Input: `
+			type Query {
+				user: User
+			}
+		`},
+		&ast.Source{Name: "user.graphql", Input: `
+			type User {
+				id: ID!
+			}
+
+			extend type Query {
+				users: [User!]!
+			}
+		`},
+		&ast.Source{Name: "name.graphql", Input: `
+			extend type User {
+				name: String
+			}
+		`},
+	)
+	require.NoError(t, err)
+
+	assert.NotNil(t, schema.Query.Fields.ForName("user"))
+	assert.NotNil(t, schema.Query.Fields.ForName("users"))
+	assert.NotNil(t, schema.Types["User"].Fields.ForName("name"))
+	assert.Equal(t, "user.graphql", schema.Types["User"].Position.Src.Name)
+}
+
+func TestLoadSchemaSources_errors(t *testing.T) {
+	t.Parallel()
+	for _, row := range []struct {
+		Message  string
+		Sources  []*ast.Source
+		Expected ErrorList
+	}{
+		{
+			Message: "syntax errors in every file",
+			Sources: []*ast.Source{
+				{Name: "a.graphql", Input: "type Query {\n  a String\n}"},
+				{Name: "b.graphql", Input: "type B {\n  b: String\n}"},
+				{Name: "c.graphql", Input: "\n\ntype C a"},
+			},
+			Expected: ErrorList{
+				&SchemaError{File: "a.graphql", Line: 2, Column: 5, Message: "Expected :, found Name"},
+				&SchemaError{File: "c.graphql", Line: 3, Column: 8, Message: "Unexpected Name \"a\""},
+			},
+		},
+		{
+			Message: "redeclarations across files",
+			Sources: []*ast.Source{
+				{Name: "a.graphql", Input: "type Query { a: String }\ntype A { a: String }\ndirective @a on FIELD"},
+				{Name: "b.graphql", Input: "type A { a: String }\ntype Query { b: String }\ndirective @a on FIELD"},
+			},
+			Expected: ErrorList{
+				&SchemaError{File: "b.graphql", Line: 1, Column: 6, Message: "Cannot redeclare type A."},
+				&SchemaError{File: "b.graphql", Line: 2, Column: 6, Message: "Cannot redeclare type Query."},
+				&SchemaError{File: "b.graphql", Line: 3, Column: 12, Message: "Cannot redeclare directive a."},
+			},
+		},
+		{
+			Message: "validation error",
+			Sources: []*ast.Source{
+				{Name: "a.graphql", Input: "type Query {\n  a: Missing\n}"},
+			},
+			Expected: ErrorList{
+				&SchemaError{File: "a.graphql", Line: 2, Column: 6, Message: "Undefined type Missing."},
+			},
+		},
+	} {
+		row := row
+		t.Run(row.Message, func(t *testing.T) {
+			t.Parallel()
+			schema, err := LoadSchemaSources(row.Sources...)
+			assert.Nil(t, schema)
+			assert.Equal(t, row.Expected, err)
+		})
+	}
+}
+
+func TestLoadSchemaGlob(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "query.graphql"), []byte(`type Query { user: User }`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "user.graphql"), []byte(`type User { id: ID! } extend type Query { me: User }`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte(`not a schema`), 0o600))
+
+	schema, err := LoadSchemaGlob(filepath.Join(dir, "*.graphql"))
+	require.NoError(t, err)
+	assert.NotNil(t, schema.Query.Fields.ForName("me"))
+	assert.NotNil(t, schema.Types["User"])
+
+	_, err = LoadSchemaGlob(filepath.Join(dir, "*.gql"))
+	assert.Error(t, err)
+
+	// errors point at the file they came from
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.graphql"), []byte(`type Broken {`), 0o600))
+	_, err = LoadSchemaGlob(filepath.Join(dir, "*.graphql"))
+	var schemaErr *SchemaError
+	require.ErrorAs(t, err, &schemaErr)
+	assert.Equal(t, filepath.Join(dir, "broken.graphql"), schemaErr.File)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sync"
 
 	"github.com/pkg/errors"
 	"github.com/vektah/gqlparser/v2"
@@ -16,10 +17,14 @@ type IntrospectOptions struct {
 	// If non-nil (i.e. created by an introspection func here), then sets its own options into opts.
 	mergeFunc func(opts *IntrospectOptions)
 
-	client  *http.Client
-	ctx     context.Context
-	retrier Retrier
-	wares   []NetworkMiddleware
+	client        *http.Client
+	ctx           context.Context
+	retrier       Retrier
+	wares         []NetworkMiddleware
+	features      introspectionQueryFeatures
+	skipDetection bool
+	federationSDL bool
+	validate      bool
 }
 
 // Context returns either a given context or an instance of the context.Background
@@ -41,6 +46,20 @@ func (o *IntrospectOptions) Apply(queryer Queryer) Queryer {
 	return queryer
 }
 
+// retry invokes fn until it succeeds or the retrier, if any, gives up
+func (o *IntrospectOptions) retry(fn func() error) error {
+	err := fn()
+	if o.retrier != nil {
+		// if available, retry on failures
+		var attempts uint = 1
+		for err != nil && o.retrier.ShouldRetry(err, attempts) {
+			err = fn()
+			attempts++
+		}
+	}
+	return err
+}
+
 func mergeIntrospectOptions(opts ...*IntrospectOptions) *IntrospectOptions {
 	res := &IntrospectOptions{}
 	for _, opt := range opts {
@@ -89,6 +108,33 @@ func IntrospectWithRetrier(retrier Retrier) *IntrospectOptions {
 	})
 }
 
+// IntrospectWithInputValueDeprecation returns an instance of graphql.IntrospectOptions that asks the remote
+// service for deprecated arguments and input fields. The remote service must support the includeDeprecated
+// argument on __Field.args, __Directive.args and __Type.inputFields.
+func IntrospectWithInputValueDeprecation() *IntrospectOptions {
+	return introspectOptsFunc(func(opts *IntrospectOptions) {
+		opts.features.inputValueDeprecation = true
+	})
+}
+
+// IntrospectWithTypeRefDepth returns an instance of graphql.IntrospectOptions that asks for type references
+// nested up to depth levels, e.g. [[String!]!]! needs 6. The default depth is 8. Deeper type references
+// cause IntrospectAPI to return an error.
+func IntrospectWithTypeRefDepth(depth int) *IntrospectOptions {
+	return introspectOptsFunc(func(opts *IntrospectOptions) {
+		opts.features.typeRefDepth = depth
+	})
+}
+
+// IntrospectWithoutFeatureDetection returns an instance of graphql.IntrospectOptions that skips asking the remote
+// service which parts of the introspection schema it supports and sends the standard introspection query, which
+// leaves out schema descriptions, repeatable directives, @specifiedBy and @oneOf.
+func IntrospectWithoutFeatureDetection() *IntrospectOptions {
+	return introspectOptsFunc(func(opts *IntrospectOptions) {
+		opts.skipDetection = true
+	})
+}
+
 // IntrospectRemoteSchema is used to build a RemoteSchema by firing the introspection query
 // at a remote service and reconstructing the schema object from the response
 func IntrospectRemoteSchema(url string, opts ...*IntrospectOptions) (*RemoteSchema, error) {
@@ -130,36 +176,128 @@ func IntrospectRemoteSchemasWithOptions(urls []string, opts ...*IntrospectOption
 	return schemas, nil
 }
 
+// RemoteSchemaError describes a failure to introspect the RemoteSchema at URL
+type RemoteSchemaError struct {
+	URL string
+	Err error
+}
+
+func (e *RemoteSchemaError) Error() string {
+	return fmt.Sprintf("could not introspect %s: %s", e.URL, e.Err)
+}
+
+// Unwrap returns the underlying introspection error
+func (e *RemoteSchemaError) Unwrap() error {
+	return e.Err
+}
+
+// IntrospectRemoteSchemasConcurrently takes a list of URLs and an optional list of graphql.IntrospectionOptions
+// and introspects up to concurrency of them at a time. A concurrency of 0 or less introspects all of them at once.
+// Unlike IntrospectRemoteSchemasWithOptions, every URL is attempted: the schemas that succeeded are returned in the
+// order of urls, along with an ErrorList of *RemoteSchemaError for the ones that failed.
+func IntrospectRemoteSchemasConcurrently(urls []string, concurrency int, opts ...*IntrospectOptions) ([]*RemoteSchema, error) {
+	if concurrency <= 0 || concurrency > len(urls) {
+		concurrency = len(urls)
+	}
+
+	// each URL gets its own slot so the results keep their order
+	schemas := make([]*RemoteSchema, len(urls))
+	errs := make([]error, len(urls))
+
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, concurrency)
+	for i, service := range urls {
+		wg.Add(1)
+		limit <- struct{}{}
+		go func(i int, service string) {
+			defer func() {
+				<-limit
+				wg.Done()
+			}()
+			schemas[i], errs[i] = IntrospectRemoteSchema(service, opts...)
+		}(i, service)
+	}
+	wg.Wait()
+
+	// split the successes from the failures
+	result := []*RemoteSchema{}
+	errList := ErrorList{}
+	for i, service := range urls {
+		if errs[i] != nil {
+			errList = append(errList, &RemoteSchemaError{URL: service, Err: errs[i]})
+			continue
+		}
+		result = append(result, schemas[i])
+	}
+
+	if len(errList) > 0 {
+		return result, errList
+	}
+	return result, nil
+}
+
 // IntrospectAPI send the introspection query to a Queryer and builds up the
-// schema object described by the result
+// schema object described by the result.
+//
+// It first asks the service which parts of the introspection schema it supports, then builds an introspection
+// query for all of them. This picks up schema descriptions, repeatable directives, @specifiedBy, @oneOf and
+// deprecated input values when available. If the service can't answer, the standard query is used.
 func IntrospectAPI(queryer Queryer, opts ...*IntrospectOptions) (*ast.Schema, error) {
 	// apply the options to the given queryer
 	opt := mergeIntrospectOptions(opts...)
 	queryer = opt.Apply(queryer)
 
-	query := func() (IntrospectionQueryResult, error) {
-		var result IntrospectionQueryResult
-		input := &QueryInput{
-			Query:         IntrospectionQuery,
-			OperationName: "IntrospectionQuery",
+	var schema *ast.Schema
+	var err error
+	if opt.federationSDL {
+		schema, err = federationSDLAPI(queryer, opt)
+	} else {
+		schema, err = introspectAPI(queryer, opt)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if opt.validate {
+		if err := ValidateSchema(schema); err != nil {
+			return nil, errors.WithMessage(err, "invalid schema")
 		}
-		err := queryer.Query(opt.Context(), input, &result)
-		return result, errors.WithMessage(err, "query failed")
 	}
-	// fire the introspection query
-	result, err := query()
-	if opt.retrier != nil {
-		// if available, retry on failures
-		var attempts uint = 1
-		for err != nil && opt.retrier.ShouldRetry(err, attempts) {
-			result, err = query()
-			attempts++
+	return schema, nil
+}
+
+// introspectAPI sends the introspection query to a queryer that the options have already been applied to
+func introspectAPI(queryer Queryer, opt *IntrospectOptions) (*ast.Schema, error) {
+	// figure out which features the remote service supports
+	features := opt.features
+	if !opt.skipDetection {
+		// a service that can't tell gets the standard query, so the detection isn't retried
+		detected, err := detectIntrospectionFeatures(opt.Context(), queryer, opt.features)
+		if err == nil {
+			features = detected
 		}
 	}
+
+	// fire the introspection query
+	var result IntrospectionQueryResult
+	err := opt.retry(func() error {
+		result = IntrospectionQueryResult{}
+		input := &QueryInput{
+			Query:         features.query(),
+			OperationName: "IntrospectionQuery",
+		}
+		err := queryer.Query(opt.Context(), input, &result)
+		return errors.WithMessage(err, "query failed")
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	return BuildSchemaFromIntrospection(&result)
+}
+
+// BuildSchemaFromIntrospection reconstructs the schema object described by the result of an introspection query
+func BuildSchemaFromIntrospection(result *IntrospectionQueryResult) (*ast.Schema, error) {
 	// grab the schema
 	remoteSchema := result.Schema
 
@@ -175,6 +313,7 @@ func IntrospectAPI(queryer Queryer, opts ...*IntrospectOptions) (*ast.Schema, er
 	if remoteSchema == nil || remoteSchema.QueryType.Name == "" {
 		return nil, errors.New("Could not find the root query")
 	}
+	schema.Description = remoteSchema.Description
 
 	// reconstructing the schema happens in a few pass throughs
 	// the first builds a map of type names to their definition
@@ -203,7 +342,7 @@ func IntrospectAPI(queryer Queryer, opts ...*IntrospectOptions) (*ast.Schema, er
 		// a reference to the type
 		storedType, ok := schema.Types[remoteType.Name]
 		if !ok {
-			return nil, err
+			return nil, fmt.Errorf("could not find type definition for %s", remoteType.Name)
 		}
 
 		// make sure we record that a type implements itself
@@ -239,7 +378,7 @@ func IntrospectAPI(queryer Queryer, opts ...*IntrospectOptions) (*ast.Schema, er
 
 				// add the possible type to the schema
 				addPossibleTypeOnce(schema, remoteType.Name, possibleTypeDef)
-				schema.AddImplements(possibleType.Name, storedType)
+				addImplementsOnce(schema, possibleType.Name, storedType)
 			}
 		}
 
@@ -261,7 +400,7 @@ func IntrospectAPI(queryer Queryer, opts ...*IntrospectOptions) (*ast.Schema, er
 
 				// add the possible type to the schema
 				addPossibleTypeOnce(schema, iFaceDef.Name, storedType)
-				schema.AddImplements(storedType.Name, iFaceDef)
+				addImplementsOnce(schema, storedType.Name, iFaceDef)
 			}
 		}
 
@@ -272,22 +411,37 @@ func IntrospectAPI(queryer Queryer, opts ...*IntrospectOptions) (*ast.Schema, er
 			// add the field to the list
 			args, err := introspectionConvertArgList(field.Args)
 			if err != nil {
-				return nil, err
+				return nil, errors.WithMessagef(err, "field %s.%s", remoteType.Name, field.Name)
+			}
+			fieldType, err := introspectionUnmarshalTypeRef(&field.Type)
+			if err != nil {
+				return nil, errors.WithMessagef(err, "field %s.%s", remoteType.Name, field.Name)
 			}
 			fields = append(fields, &ast.FieldDefinition{
 				Name:        field.Name,
-				Type:        introspectionUnmarshalTypeRef(&field.Type),
+				Type:        fieldType,
 				Description: field.Description,
 				Arguments:   args,
+				Directives:  introspectionDeprecatedDirectives(field.IsDeprecated, field.DeprecationReason),
 			})
 		}
 
 		for _, field := range remoteType.InputFields {
 			// add the field to the list
+			fieldType, err := introspectionUnmarshalTypeRef(&field.Type)
+			if err != nil {
+				return nil, errors.WithMessagef(err, "input field %s.%s", remoteType.Name, field.Name)
+			}
+			defaultValue, err := introspectionUnmarshalArgumentDefaultValue(field)
+			if err != nil {
+				return nil, errors.WithMessagef(err, "input field %s.%s", remoteType.Name, field.Name)
+			}
 			fields = append(fields, &ast.FieldDefinition{
-				Name:        field.Name,
-				Type:        introspectionUnmarshalTypeRef(&field.Type),
-				Description: field.Description,
+				Name:         field.Name,
+				Type:         fieldType,
+				Description:  field.Description,
+				DefaultValue: defaultValue,
+				Directives:   introspectionDeprecatedDirectives(field.IsDeprecated, field.DeprecationReason),
 			})
 		}
 
@@ -295,6 +449,13 @@ func IntrospectAPI(queryer Queryer, opts ...*IntrospectOptions) (*ast.Schema, er
 		storedType.Fields = fields
 	}
 
+	// interfaces can implement other interfaces so record every type against all of its ancestors
+	for _, remoteType := range remoteSchema.Types {
+		if err := introspectionAddInterfaceAncestors(schema, schema.Types[remoteType.Name]); err != nil {
+			return nil, err
+		}
+	}
+
 	// add each directive to the schema
 	for _, directive := range remoteSchema.Directives {
 		// if we dont have a name
@@ -311,21 +472,35 @@ func IntrospectAPI(queryer Queryer, opts ...*IntrospectOptions) (*ast.Schema, er
 		// save the directive definition to the schema
 		args, err := introspectionConvertArgList(directive.Args)
 		if err != nil {
-			return nil, err
+			return nil, errors.WithMessagef(err, "directive @%s", directive.Name)
 		}
 		schema.Directives[directive.Name] = &ast.DirectiveDefinition{
-			Position:    &ast.Position{Src: &ast.Source{}},
-			Name:        directive.Name,
-			Description: directive.Description,
-			Arguments:   args,
-			Locations:   locations,
+			Position:     &ast.Position{Src: &ast.Source{}},
+			Name:         directive.Name,
+			Description:  directive.Description,
+			Arguments:    args,
+			Locations:    locations,
+			IsRepeatable: directive.IsRepeatable,
 		}
-		switch directive.Name {
-		case "skip", "deprecated", "include":
+		if isBuiltInDirectiveName(directive.Name) {
 			schema.Directives[directive.Name].Position.Src.BuiltIn = true
 		}
 	}
 
+	// services that support @oneOf don't always list it with the other directives
+	if _, ok := schema.Directives["oneOf"]; !ok && introspectionUsesDirective(schema, "oneOf") {
+		schema.Directives["oneOf"] = &ast.DirectiveDefinition{
+			Position:    &ast.Position{Src: &ast.Source{}},
+			Name:        "oneOf",
+			Description: "Indicates exactly one field must be supplied and this field must not be `null`.",
+			Arguments:   ast.ArgumentDefinitionList{},
+			Locations:   []ast.DirectiveLocation{ast.LocationInputObject},
+		}
+	}
+
+	// point any directives we attached along the way at their definitions
+	introspectionLinkDirectives(schema)
+
 	// we're done here
 	return schema, nil
 }
@@ -339,6 +514,51 @@ func addPossibleTypeOnce(schema *ast.Schema, name string, definition *ast.Defini
 	schema.AddPossibleType(name, definition)
 }
 
+func addImplementsOnce(schema *ast.Schema, name string, iface *ast.Definition) {
+	for _, typ := range schema.Implements[name] {
+		if typ.Name == iface.Name {
+			return
+		}
+	}
+	schema.AddImplements(name, iface)
+}
+
+// introspectionAddInterfaceAncestors walks up the interfaces implemented by the definition and adds it as a possible type
+// of each one, so fragments on an ancestor interface match types that only implement it through another interface
+func introspectionAddInterfaceAncestors(schema *ast.Schema, definition *ast.Definition) error {
+	visited := map[string]bool{}
+
+	var visit func(current *ast.Definition) error
+	visit = func(current *ast.Definition) error {
+		for _, name := range current.Interfaces {
+			iface, ok := schema.Types[name]
+			if !ok {
+				return fmt.Errorf("%s implements unknown interface %s", current.Name, name)
+			}
+			if iface.Kind != ast.Interface {
+				return fmt.Errorf("%s cannot implement %s because it is not an interface", current.Name, name)
+			}
+			if name == definition.Name {
+				return fmt.Errorf("interface %s cannot implement itself", name)
+			}
+			if visited[name] {
+				continue
+			}
+			visited[name] = true
+
+			addPossibleTypeOnce(schema, name, definition)
+			addImplementsOnce(schema, definition.Name, iface)
+
+			if err := visit(iface); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return visit(definition)
+}
+
 func introspectionConvertArgList(args []IntrospectionInputValue) (ast.ArgumentDefinitionList, error) {
 	result := ast.ArgumentDefinitionList{}
 
@@ -348,23 +568,127 @@ func introspectionConvertArgList(args []IntrospectionInputValue) (ast.ArgumentDe
 		if err != nil {
 			return nil, err
 		}
+		argumentType, err := introspectionUnmarshalTypeRef(&argument.Type)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "argument %s", argument.Name)
+		}
 		result = append(result, &ast.ArgumentDefinition{
 			Name:         argument.Name,
 			Description:  argument.Description,
-			Type:         introspectionUnmarshalTypeRef(&argument.Type),
+			Type:         argumentType,
 			DefaultValue: defaultValue,
+			Directives:   introspectionDeprecatedDirectives(argument.IsDeprecated, argument.DeprecationReason),
 		})
 	}
 
 	return result, nil
 }
 
+// introspectionDeprecatedDirectives returns the @deprecated directive that gqlparser would have
+// produced from the equivalent SDL, or nil if the value is not deprecated
+func introspectionDeprecatedDirectives(isDeprecated bool, reason string) ast.DirectiveList {
+	if !isDeprecated {
+		return nil
+	}
+
+	directive := &ast.Directive{
+		Name:     "deprecated",
+		Position: &ast.Position{},
+	}
+	// a missing reason means the server relied on the directive's default
+	if reason != "" {
+		directive.Arguments = ast.ArgumentList{
+			{
+				Name: "reason",
+				Value: &ast.Value{
+					Kind:     ast.StringValue,
+					Raw:      reason,
+					Position: &ast.Position{},
+				},
+				Position: &ast.Position{},
+			},
+		}
+	}
+
+	return ast.DirectiveList{directive}
+}
+
+// introspectionLinkDirectives points every directive attached to the schema's definitions at the
+// matching directive definition, like gqlparser's validator does for schemas loaded from SDL
+func introspectionLinkDirectives(schema *ast.Schema) {
+	link := func(directives ast.DirectiveList) {
+		for _, directive := range directives {
+			if directive.Definition == nil {
+				directive.Definition = schema.Directives[directive.Name]
+			}
+		}
+	}
+
+	for _, definition := range schema.Types {
+		link(definition.Directives)
+
+		for _, field := range definition.Fields {
+			link(field.Directives)
+			for _, arg := range field.Arguments {
+				link(arg.Directives)
+			}
+		}
+
+		for _, value := range definition.EnumValues {
+			link(value.Directives)
+		}
+	}
+
+	for _, directive := range schema.Directives {
+		for _, arg := range directive.Arguments {
+			link(arg.Directives)
+		}
+	}
+}
+
+// introspectionUsesDirective returns true if any type in the schema has the named directive applied to it
+func introspectionUsesDirective(schema *ast.Schema, name string) bool {
+	for _, definition := range schema.Types {
+		if definition.Directives.ForName(name) != nil {
+			return true
+		}
+	}
+	return false
+}
+
 func introspectionUnmarshalType(schemaType IntrospectionQueryFullType) *ast.Definition {
 	definition := &ast.Definition{
 		Name:        schemaType.Name,
 		Description: schemaType.Description,
 	}
 
+	// scalars can point to their specification
+	if schemaType.SpecifiedByURL != "" {
+		definition.Directives = append(definition.Directives, &ast.Directive{
+			Name:     "specifiedBy",
+			Position: &ast.Position{},
+			Arguments: ast.ArgumentList{
+				{
+					Name: "url",
+					Value: &ast.Value{
+						Kind:     ast.StringValue,
+						Raw:      schemaType.SpecifiedByURL,
+						Position: &ast.Position{},
+					},
+					Position: &ast.Position{},
+				},
+			},
+		})
+	}
+
+	// input objects can require exactly one field
+	if schemaType.IsOneOf {
+		definition.Directives = append(definition.Directives, &ast.Directive{
+			Name:     "oneOf",
+			Position: &ast.Position{},
+		})
+	}
+
 	// the kind of type
 	switch schemaType.Kind {
 	case "OBJECT":
@@ -387,6 +711,7 @@ func introspectionUnmarshalType(schemaType IntrospectionQueryFullType) *ast.Defi
 			definition.EnumValues = append(definition.EnumValues, &ast.EnumValueDefinition{
 				Name:        value.Name,
 				Description: value.Description,
+				Directives:  introspectionDeprecatedDirectives(value.IsDeprecated, value.DeprecationReason),
 			})
 		}
 	}
@@ -399,7 +724,7 @@ func introspectionUnmarshalType(schemaType IntrospectionQueryFullType) *ast.Defi
 	return definition
 }
 
-// introspectionUnmarshalArgumentDefaultValue returns the *ast.Value form of an argument's default value.
+// introspectionUnmarshalArgumentDefaultValue returns the *ast.Value form of an argument's or input field's default value.
 //
 // The tricky part here is the default value comes in as a string, so it's non-trivial to unmarshal.
 // This takes advantage of gqlparser's loose validation when parsing an argument's default value even if the type is wrong (to avoid including full definitions of custom types).
@@ -444,26 +769,42 @@ func introspectionUnmarshalDirectiveLocation(locs []string) ([]ast.DirectiveLoca
 	return result, nil
 }
 
-func introspectionUnmarshalTypeRef(response *IntrospectionTypeRef) *ast.Type {
-	// we could have a non-null list of a field
-	if response.Kind == "NON_NULL" && response.OfType.Kind == "LIST" {
-		return ast.NonNullListType(introspectionUnmarshalTypeRef(response.OfType.OfType), &ast.Position{})
-	}
-
-	// we could have a list of a type
-	if response.Kind == "LIST" {
-		return ast.ListType(introspectionUnmarshalTypeRef(response.OfType), &ast.Position{})
-	}
+// introspectionUnmarshalTypeRef converts any nesting of LIST and NON_NULL wrappers into the equivalent *ast.Type.
+// Wrappers without an ofType mean the response was cut off by the depth of the TypeRef fragment.
+func introspectionUnmarshalTypeRef(response *IntrospectionTypeRef) (*ast.Type, error) {
+	switch response.Kind {
+	case "NON_NULL":
+		if response.OfType == nil {
+			return nil, errTruncatedTypeRef
+		}
+		inner, err := introspectionUnmarshalTypeRef(response.OfType)
+		if err != nil {
+			return nil, err
+		}
+		if inner.NonNull {
+			return nil, errors.New("non-null type reference wraps another non-null type")
+		}
+		inner.NonNull = true
+		return inner, nil
 
-	// we could have just a non null
-	if response.Kind == "NON_NULL" {
-		return ast.NonNullNamedType(response.OfType.Name, &ast.Position{})
+	case "LIST":
+		if response.OfType == nil {
+			return nil, errTruncatedTypeRef
+		}
+		inner, err := introspectionUnmarshalTypeRef(response.OfType)
+		if err != nil {
+			return nil, err
+		}
+		return ast.ListType(inner, &ast.Position{}), nil
 	}
 
 	// if we are looking at a named type that isn't in a list or marked non-null
-	return ast.NamedType(response.Name, &ast.Position{})
+	return ast.NamedType(response.Name, &ast.Position{}), nil
 }
 
+// errTruncatedTypeRef is returned when a type reference is nested deeper than the introspection query asked for
+var errTruncatedTypeRef = errors.New("type reference was truncated by the introspection query, increase the depth with IntrospectWithTypeRefDepth")
+
 func init() {
 	directiveLocationMap = map[string]ast.DirectiveLocation{
 		"QUERY":                  ast.LocationQuery,
@@ -492,6 +833,7 @@ type IntrospectionQueryResult struct {
 }
 
 type IntrospectionQuerySchema struct {
+	Description      string                        `json:"description"`
 	QueryType        IntrospectionQueryRootType    `json:"queryType"`
 	MutationType     *IntrospectionQueryRootType   `json:"mutationType"`
 	SubscriptionType *IntrospectionQueryRootType   `json:"subscriptionType"`
@@ -500,10 +842,11 @@ type IntrospectionQuerySchema struct {
 }
 
 type IntrospectionQueryDirective struct {
-	Name        string                    `json:"name"`
-	Description string                    `json:"description"`
-	Locations   []string                  `json:"locations"`
-	Args        []IntrospectionInputValue `json:"args"`
+	Name         string                    `json:"name"`
+	Description  string                    `json:"description"`
+	Locations    []string                  `json:"locations"`
+	Args         []IntrospectionInputValue `json:"args"`
+	IsRepeatable bool                      `json:"isRepeatable"`
 }
 
 type IntrospectionQueryRootType struct {
@@ -520,14 +863,16 @@ type IntrospectionQueryFullTypeField struct {
 }
 
 type IntrospectionQueryFullType struct {
-	Kind          string                             `json:"kind"`
-	Name          string                             `json:"name"`
-	Description   string                             `json:"description"`
-	InputFields   []IntrospectionInputValue          `json:"inputFields"`
-	Interfaces    []IntrospectionTypeRef             `json:"interfaces"`
-	PossibleTypes []IntrospectionTypeRef             `json:"possibleTypes"`
-	Fields        []IntrospectionQueryFullTypeField  `json:"fields"`
-	EnumValues    []IntrospectionQueryEnumDefinition `json:"enumValues"`
+	Kind           string                             `json:"kind"`
+	Name           string                             `json:"name"`
+	Description    string                             `json:"description"`
+	InputFields    []IntrospectionInputValue          `json:"inputFields"`
+	Interfaces     []IntrospectionTypeRef             `json:"interfaces"`
+	PossibleTypes  []IntrospectionTypeRef             `json:"possibleTypes"`
+	Fields         []IntrospectionQueryFullTypeField  `json:"fields"`
+	EnumValues     []IntrospectionQueryEnumDefinition `json:"enumValues"`
+	SpecifiedByURL string                             `json:"specifiedByURL"`
+	IsOneOf        bool                               `json:"isOneOf"`
 }
 
 type IntrospectionQueryEnumDefinition struct {
@@ -538,10 +883,12 @@ type IntrospectionQueryEnumDefinition struct {
 }
 
 type IntrospectionInputValue struct {
-	Name         string               `json:"name"`
-	Description  string               `json:"description"`
-	DefaultValue string               `json:"defaultValue"`
-	Type         IntrospectionTypeRef `json:"type"`
+	Name              string               `json:"name"`
+	Description       string               `json:"description"`
+	DefaultValue      string               `json:"defaultValue"`
+	Type              IntrospectionTypeRef `json:"type"`
+	IsDeprecated      bool                 `json:"isDeprecated"`
+	DeprecationReason string               `json:"deprecationReason"`
 }
 
 type IntrospectionTypeRef struct {
@@ -549,101 +896,3 @@ type IntrospectionTypeRef struct {
 	Name   string                `json:"name"`
 	OfType *IntrospectionTypeRef `json:"ofType"`
 }
-
-// IntrospectionQuery is the query that is fired at an API to reconstruct its schema
-var IntrospectionQuery = `
-	query IntrospectionQuery {
-		__schema {
-			queryType { name }
-			mutationType { name }
-			subscriptionType { name }
-			types {
-				...FullType
-			}
-			directives {
-				name
-				description
-				locations
-				args {
-				...InputValue
-				}
-			}
-		}
-	}
-
-	fragment FullType on __Type {
-		kind
-		name
-		description
-		fields(includeDeprecated: true) {
-			name
-			description
-			args {
-				...InputValue
-			}
-			type {
-				...TypeRef
-			}
-			isDeprecated
-			deprecationReason
-		}
-
-		inputFields {
-			...InputValue
-		}
-
-		interfaces {
-			...TypeRef
-		}
-
-		enumValues(includeDeprecated: true) {
-			name
-			description
-			isDeprecated
-			deprecationReason
-		}
-		possibleTypes {
-			...TypeRef
-		}
-	}
-
-	fragment InputValue on __InputValue {
-		name
-		description
-		type { ...TypeRef }
-		defaultValue
-	}
-
-	fragment TypeRef on __Type {
-		kind
-		name
-		ofType {
-			kind
-			name
-			ofType {
-				kind
-				name
-				ofType {
-					kind
-					name
-					ofType {
-						kind
-						name
-						ofType {
-							kind
-							name
-							ofType {
-								kind
-								name
-								ofType {
-									kind
-									name
-								}
-							}
-						}
-					}
-				}
-			}
-		}
-	}
-`
@@ -0,0 +1,623 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// SchemaIntrospectionQueryer answers introspection queries, like the IntrospectionQuery, with a description of
+// the provided schema. Fields outside of __schema, __type and __typename are not supported.
+type SchemaIntrospectionQueryer struct {
+	Schema *ast.Schema
+}
+
+// NewSchemaIntrospectionQueryer returns a SchemaIntrospectionQueryer for the given schema
+func NewSchemaIntrospectionQueryer(schema *ast.Schema) *SchemaIntrospectionQueryer {
+	return &SchemaIntrospectionQueryer{Schema: schema}
+}
+
+// Query executes the introspection query against the schema and writes the response to the receiver
+func (q *SchemaIntrospectionQueryer) Query(ctx context.Context, input *QueryInput, receiver interface{}) error {
+	data, err := ExecuteIntrospectionQuery(q.Schema, input)
+	if err != nil {
+		return err
+	}
+
+	// assign the result to the receiver the same way the network queryers do
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		TagName: "json",
+		Result:  receiver,
+	})
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(data)
+}
+
+// ExecuteIntrospectionQuery executes the introspection query in the input against the schema and returns
+// the value that belongs under the "data" key of the response
+func ExecuteIntrospectionQuery(schema *ast.Schema, input *QueryInput) (map[string]interface{}, error) {
+	document := input.QueryDocument
+	if document == nil {
+		parsed, err := parser.ParseQuery(&ast.Source{Input: input.Query})
+		if err != nil {
+			return nil, err
+		}
+		document = parsed
+	}
+
+	operation := document.Operations.ForName(input.OperationName)
+	if operation == nil {
+		return nil, fmt.Errorf("could not find operation %q", input.OperationName)
+	}
+	if operation.Operation != ast.Query {
+		return nil, fmt.Errorf("cannot introspect with a %s operation", operation.Operation)
+	}
+
+	executor := &introspectionExecutor{
+		schema:    schema,
+		fragments: document.Fragments,
+		variables: input.Variables,
+		types:     map[string]*IntrospectionQueryFullType{},
+	}
+	return executor.selectObject(introspectionRoot{}, operation.SelectionSet)
+}
+
+// introspectionExecutorTypes describe the introspection types with every field and argument the executor resolves,
+// which the ones gqlparser adds to a schema predate. Services asking for them, like IntrospectAPI when it detects the
+// features of a service, see everything the executor can answer.
+var introspectionExecutorTypes = func() map[string]*ast.Definition {
+	document, err := parser.ParseSchema(&ast.Source{Name: "introspection", Input: introspectionExecutorSchema})
+	if err != nil {
+		panic(err)
+	}
+	types := map[string]*ast.Definition{}
+	for _, definition := range document.Definitions {
+		types[definition.Name] = definition
+	}
+	return types
+}()
+
+const introspectionExecutorSchema = `
+	type __Type {
+		kind: __TypeKind!
+		name: String
+		description: String
+		fields(includeDeprecated: Boolean = false): [__Field!]
+		interfaces: [__Type!]
+		possibleTypes: [__Type!]
+		enumValues(includeDeprecated: Boolean = false): [__EnumValue!]
+		inputFields(includeDeprecated: Boolean = false): [__InputValue!]
+		ofType: __Type
+		specifiedByURL: String
+		isOneOf: Boolean
+	}
+
+	type __Field {
+		name: String!
+		description: String
+		args(includeDeprecated: Boolean = false): [__InputValue!]!
+		type: __Type!
+		isDeprecated: Boolean!
+		deprecationReason: String
+	}
+
+	type __InputValue {
+		name: String!
+		description: String
+		type: __Type!
+		defaultValue: String
+		isDeprecated: Boolean!
+		deprecationReason: String
+	}
+
+	type __Directive {
+		name: String!
+		description: String
+		locations: [__DirectiveLocation!]!
+		args(includeDeprecated: Boolean = false): [__InputValue!]!
+		isRepeatable: Boolean!
+	}
+`
+
+// introspectionRoot is the query type of an introspection query
+type introspectionRoot struct{}
+
+// introspectionExecutor resolves the fields of an introspection query against the marshalled form of a schema,
+// the same one NewIntrospectionQueryResult builds
+type introspectionExecutor struct {
+	schema    *ast.Schema
+	fragments ast.FragmentDefinitionList
+	variables map[string]interface{}
+
+	// the types marshalled so far, by name
+	types map[string]*IntrospectionQueryFullType
+}
+
+// typeName returns the name of the introspection type of an object value
+func (e *introspectionExecutor) typeName(object interface{}) string {
+	switch object.(type) {
+	case introspectionRoot:
+		if e.schema.Query != nil {
+			return e.schema.Query.Name
+		}
+		return "Query"
+	case *IntrospectionQuerySchema:
+		return "__Schema"
+	case *IntrospectionQueryFullType, *IntrospectionTypeRef:
+		return "__Type"
+	case *IntrospectionQueryFullTypeField:
+		return "__Field"
+	case *IntrospectionInputValue:
+		return "__InputValue"
+	case *IntrospectionQueryEnumDefinition:
+		return "__EnumValue"
+	case *IntrospectionQueryDirective:
+		return "__Directive"
+	}
+	return ""
+}
+
+// selectObject resolves the selection set against an object value
+func (e *introspectionExecutor) selectObject(object interface{}, selectionSet ast.SelectionSet) (map[string]interface{}, error) {
+	// group the fields by response key so repeated fields merge their selections
+	keys := []string{}
+	groups := map[string][]*ast.Field{}
+	if err := e.collectFields(e.typeName(object), selectionSet, &keys, groups); err != nil {
+		return nil, err
+	}
+
+	result := map[string]interface{}{}
+	for _, key := range keys {
+		fields := groups[key]
+
+		value, err := e.resolve(object, fields[0])
+		if err != nil {
+			return nil, err
+		}
+
+		subSelection := ast.SelectionSet{}
+		for _, field := range fields {
+			subSelection = append(subSelection, field.SelectionSet...)
+		}
+
+		result[key], err = e.complete(value, subSelection)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (e *introspectionExecutor) collectFields(typeName string, selectionSet ast.SelectionSet, keys *[]string, groups map[string][]*ast.Field) error {
+	for _, selection := range selectionSet {
+		skip, err := e.skip(selectionDirectives(selection))
+		if err != nil {
+			return err
+		}
+		if skip {
+			continue
+		}
+
+		switch selection := selection.(type) {
+		case *ast.Field:
+			key := selection.Name
+			if selection.Alias != "" {
+				key = selection.Alias
+			}
+			if _, ok := groups[key]; !ok {
+				*keys = append(*keys, key)
+			}
+			groups[key] = append(groups[key], selection)
+
+		case *ast.InlineFragment:
+			if selection.TypeCondition != "" && selection.TypeCondition != typeName {
+				continue
+			}
+			if err := e.collectFields(typeName, selection.SelectionSet, keys, groups); err != nil {
+				return err
+			}
+
+		case *ast.FragmentSpread:
+			definition := e.fragments.ForName(selection.Name)
+			if definition == nil {
+				return fmt.Errorf("could not find fragment definition: %s", selection.Name)
+			}
+			if definition.TypeCondition != typeName {
+				continue
+			}
+			if err := e.collectFields(typeName, definition.SelectionSet, keys, groups); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func selectionDirectives(selection ast.Selection) ast.DirectiveList {
+	switch selection := selection.(type) {
+	case *ast.Field:
+		return selection.Directives
+	case *ast.InlineFragment:
+		return selection.Directives
+	case *ast.FragmentSpread:
+		return selection.Directives
+	}
+	return nil
+}
+
+// skip applies the @skip and @include directives
+func (e *introspectionExecutor) skip(directives ast.DirectiveList) (bool, error) {
+	for _, directive := range directives {
+		if directive.Name != "skip" && directive.Name != "include" {
+			continue
+		}
+		arg := directive.Arguments.ForName("if")
+		if arg == nil {
+			return false, fmt.Errorf("@%s is missing the if argument", directive.Name)
+		}
+		value, err := arg.Value.Value(e.variables)
+		if err != nil {
+			return false, err
+		}
+		condition, _ := value.(bool)
+		if condition == (directive.Name == "skip") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// complete turns a resolved value into its response form, selecting fields from objects
+func (e *introspectionExecutor) complete(value interface{}, selectionSet ast.SelectionSet) (interface{}, error) {
+	switch value := value.(type) {
+	case nil:
+		return nil, nil
+	case []interface{}:
+		result := []interface{}{}
+		for _, item := range value {
+			completed, err := e.complete(item, selectionSet)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, completed)
+		}
+		return result, nil
+	case *string:
+		if value == nil {
+			return nil, nil
+		}
+		return *value, nil
+	case *IntrospectionQuerySchema, *IntrospectionQueryFullType, *IntrospectionTypeRef, *IntrospectionQueryFullTypeField,
+		*IntrospectionInputValue, *IntrospectionQueryEnumDefinition, *IntrospectionQueryDirective:
+		return e.selectObject(value, selectionSet)
+	}
+	return value, nil
+}
+
+// booleanArgument returns the value of a boolean argument, or false if it is missing
+func (e *introspectionExecutor) booleanArgument(field *ast.Field, name string) (bool, error) {
+	arg := field.Arguments.ForName(name)
+	if arg == nil {
+		return false, nil
+	}
+	value, err := arg.Value.Value(e.variables)
+	if err != nil {
+		return false, err
+	}
+	result, _ := value.(bool)
+	return result, nil
+}
+
+// resolve returns the value of a field on an object value
+func (e *introspectionExecutor) resolve(object interface{}, field *ast.Field) (interface{}, error) {
+	if field.Name == "__typename" {
+		return e.typeName(object), nil
+	}
+
+	var value interface{}
+	var err error
+	var ok bool
+	switch object := object.(type) {
+	case introspectionRoot:
+		value, ok, err = e.resolveRoot(field)
+	case *IntrospectionQuerySchema:
+		value, ok = e.resolveSchema(object, field)
+	case *IntrospectionQueryFullType:
+		value, ok, err = e.resolveType(object, field)
+	case *IntrospectionTypeRef:
+		value, ok = e.resolveWrappingType(object, field)
+	case *IntrospectionQueryFullTypeField:
+		value, ok, err = e.resolveField(object, field)
+	case *IntrospectionInputValue:
+		value, ok = e.resolveInputValue(object, field)
+	case *IntrospectionQueryEnumDefinition:
+		value, ok = e.resolveEnumValue(object, field)
+	case *IntrospectionQueryDirective:
+		value, ok, err = e.resolveDirective(object, field)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("cannot query field %q on type %q", field.Name, e.typeName(object))
+	}
+	return value, nil
+}
+
+func (e *introspectionExecutor) resolveRoot(field *ast.Field) (interface{}, bool, error) {
+	switch field.Name {
+	case "__schema":
+		schema := NewIntrospectionQueryResult(e.schema).Schema
+		// the types of the schema are the ones the type references resolve to
+		for i := range schema.Types {
+			if definition, ok := introspectionExecutorTypes[schema.Types[i].Name]; ok {
+				schema.Types[i] = introspectionMarshalType(e.schema, definition)
+			}
+			e.types[schema.Types[i].Name] = &schema.Types[i]
+		}
+		return schema, true, nil
+	case "__type":
+		arg := field.Arguments.ForName("name")
+		if arg == nil {
+			return nil, true, fmt.Errorf("__type is missing the name argument")
+		}
+		name, err := arg.Value.Value(e.variables)
+		if err != nil {
+			return nil, true, err
+		}
+		nameString, _ := name.(string)
+		if _, ok := e.schema.Types[nameString]; !ok {
+			return nil, true, nil
+		}
+		return e.namedType(nameString), true, nil
+	}
+	return nil, false, nil
+}
+
+// namedType returns the marshalled form of the type with the given name
+func (e *introspectionExecutor) namedType(name string) *IntrospectionQueryFullType {
+	if typ, ok := e.types[name]; ok {
+		return typ
+	}
+
+	definition, ok := introspectionExecutorTypes[name]
+	if !ok {
+		definition, ok = e.schema.Types[name]
+	}
+	if !ok {
+		definition = &ast.Definition{Name: name}
+	}
+	typ := introspectionMarshalType(e.schema, definition)
+	e.types[name] = &typ
+	return &typ
+}
+
+// typeRef returns the __Type a reference points to: the named type itself, or the LIST or NON_NULL wrapping another type
+func (e *introspectionExecutor) typeRef(ref *IntrospectionTypeRef) interface{} {
+	if ref.OfType != nil {
+		return ref
+	}
+	return e.namedType(ref.Name)
+}
+
+// typeRefList returns the __Type list for the references
+func (e *introspectionExecutor) typeRefList(refs []IntrospectionTypeRef) interface{} {
+	if refs == nil {
+		return nil
+	}
+	result := []interface{}{}
+	for i := range refs {
+		result = append(result, e.typeRef(&refs[i]))
+	}
+	return result
+}
+
+func (e *introspectionExecutor) resolveSchema(schema *IntrospectionQuerySchema, field *ast.Field) (interface{}, bool) {
+	switch field.Name {
+	case "description":
+		return nullableString(schema.Description), true
+	case "types":
+		types := []interface{}{}
+		for i := range schema.Types {
+			types = append(types, &schema.Types[i])
+		}
+		return types, true
+	case "queryType":
+		if schema.QueryType.Name == "" {
+			return nil, true
+		}
+		return e.namedType(schema.QueryType.Name), true
+	case "mutationType":
+		return e.rootType(schema.MutationType), true
+	case "subscriptionType":
+		return e.rootType(schema.SubscriptionType), true
+	case "directives":
+		directives := []interface{}{}
+		for i := range schema.Directives {
+			directives = append(directives, &schema.Directives[i])
+		}
+		return directives, true
+	}
+	return nil, false
+}
+
+// rootType returns the __Type for a root type, or nil if there is none
+func (e *introspectionExecutor) rootType(root *IntrospectionQueryRootType) interface{} {
+	if root == nil {
+		return nil
+	}
+	return e.namedType(root.Name)
+}
+
+// resolveWrappingType resolves a LIST or NON_NULL type, which only has a kind and the type it wraps
+func (e *introspectionExecutor) resolveWrappingType(ref *IntrospectionTypeRef, field *ast.Field) (interface{}, bool) {
+	switch field.Name {
+	case "kind":
+		return ref.Kind, true
+	case "ofType":
+		return e.typeRef(ref.OfType), true
+	case "name", "description", "specifiedByURL", "fields", "interfaces", "possibleTypes", "enumValues", "inputFields", "isOneOf":
+		return nil, true
+	}
+	return nil, false
+}
+
+func (e *introspectionExecutor) resolveType(typ *IntrospectionQueryFullType, field *ast.Field) (interface{}, bool, error) {
+	switch field.Name {
+	case "kind":
+		return typ.Kind, true, nil
+	case "name":
+		return typ.Name, true, nil
+	case "description":
+		return nullableString(typ.Description), true, nil
+	case "specifiedByURL":
+		return nullableString(typ.SpecifiedByURL), true, nil
+	case "ofType":
+		return nil, true, nil
+	case "isOneOf":
+		if typ.Kind != string(ast.InputObject) {
+			return nil, true, nil
+		}
+		return typ.IsOneOf, true, nil
+	case "interfaces":
+		return e.typeRefList(typ.Interfaces), true, nil
+	case "possibleTypes":
+		return e.typeRefList(typ.PossibleTypes), true, nil
+
+	case "fields":
+		if typ.Fields == nil {
+			return nil, true, nil
+		}
+		includeDeprecated, err := e.booleanArgument(field, "includeDeprecated")
+		if err != nil {
+			return nil, true, err
+		}
+		fields := []interface{}{}
+		for i := range typ.Fields {
+			if typ.Fields[i].IsDeprecated && !includeDeprecated {
+				continue
+			}
+			fields = append(fields, &typ.Fields[i])
+		}
+		return fields, true, nil
+
+	case "enumValues":
+		if typ.EnumValues == nil {
+			return nil, true, nil
+		}
+		includeDeprecated, err := e.booleanArgument(field, "includeDeprecated")
+		if err != nil {
+			return nil, true, err
+		}
+		values := []interface{}{}
+		for i := range typ.EnumValues {
+			if typ.EnumValues[i].IsDeprecated && !includeDeprecated {
+				continue
+			}
+			values = append(values, &typ.EnumValues[i])
+		}
+		return values, true, nil
+
+	case "inputFields":
+		if typ.InputFields == nil {
+			return nil, true, nil
+		}
+		inputFields, err := e.inputValueList(typ.InputFields, field)
+		return inputFields, true, err
+	}
+	return nil, false, nil
+}
+
+func (e *introspectionExecutor) resolveField(definition *IntrospectionQueryFullTypeField, field *ast.Field) (interface{}, bool, error) {
+	switch field.Name {
+	case "name":
+		return definition.Name, true, nil
+	case "description":
+		return nullableString(definition.Description), true, nil
+	case "args":
+		args, err := e.inputValueList(definition.Args, field)
+		return args, true, err
+	case "type":
+		return e.typeRef(&definition.Type), true, nil
+	case "isDeprecated":
+		return definition.IsDeprecated, true, nil
+	case "deprecationReason":
+		return nullableString(definition.DeprecationReason), true, nil
+	}
+	return nil, false, nil
+}
+
+// inputValueList returns the __InputValue list for values, honoring includeDeprecated on the field selecting them
+func (e *introspectionExecutor) inputValueList(values []IntrospectionInputValue, field *ast.Field) (interface{}, error) {
+	includeDeprecated, err := e.booleanArgument(field, "includeDeprecated")
+	if err != nil {
+		return nil, err
+	}
+
+	result := []interface{}{}
+	for i := range values {
+		if values[i].IsDeprecated && !includeDeprecated {
+			continue
+		}
+		result = append(result, &values[i])
+	}
+	return result, nil
+}
+
+func (e *introspectionExecutor) resolveInputValue(value *IntrospectionInputValue, field *ast.Field) (interface{}, bool) {
+	switch field.Name {
+	case "name":
+		return value.Name, true
+	case "description":
+		return nullableString(value.Description), true
+	case "type":
+		return e.typeRef(&value.Type), true
+	case "defaultValue":
+		return nullableString(value.DefaultValue), true
+	case "isDeprecated":
+		return value.IsDeprecated, true
+	case "deprecationReason":
+		return nullableString(value.DeprecationReason), true
+	}
+	return nil, false
+}
+
+func (e *introspectionExecutor) resolveEnumValue(value *IntrospectionQueryEnumDefinition, field *ast.Field) (interface{}, bool) {
+	switch field.Name {
+	case "name":
+		return value.Name, true
+	case "description":
+		return nullableString(value.Description), true
+	case "isDeprecated":
+		return value.IsDeprecated, true
+	case "deprecationReason":
+		return nullableString(value.DeprecationReason), true
+	}
+	return nil, false
+}
+
+func (e *introspectionExecutor) resolveDirective(directive *IntrospectionQueryDirective, field *ast.Field) (interface{}, bool, error) {
+	switch field.Name {
+	case "name":
+		return directive.Name, true, nil
+	case "description":
+		return nullableString(directive.Description), true, nil
+	case "locations":
+		locations := []interface{}{}
+		for _, location := range directive.Locations {
+			locations = append(locations, location)
+		}
+		return locations, true, nil
+	case "args":
+		args, err := e.inputValueList(directive.Args, field)
+		return args, true, err
+	case "isRepeatable":
+		return directive.IsRepeatable, true, nil
+	}
+	return nil, false, nil
+}
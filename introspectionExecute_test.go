@@ -0,0 +1,247 @@
+package graphql
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecuteIntrospectionQuery_roundTrip(t *testing.T) {
+	t.Parallel()
+	for _, row := range []struct {
+		Message string
+		Schema  string
+	}{
+		{
+			Message: "objects and scalars",
+			Schema: `
+				"The root"
+				type Query {
+					"says hello"
+					hello(name: String = "world", times: Int! = 1): String!
+					list: [[Int!]]!
+					user: User
+				}
+
+				type User {
+					id: ID!
+					old: String @deprecated(reason: "use new")
+					new: String
+				}
+			`,
+		},
+		{
+			Message: "interfaces and unions",
+			Schema: `
+				type Query {
+					node: Node
+					search: [Result!]!
+				}
+
+				interface Node {
+					id: ID!
+				}
+
+				interface Entity implements Node {
+					id: ID!
+				}
+
+				type User implements Node & Entity {
+					id: ID!
+				}
+
+				type Post implements Node {
+					id: ID!
+				}
+
+				union Result = User | Post
+			`,
+		},
+		{
+			Message: "inputs and enums",
+			Schema: `
+				type Query {
+					find(filter: Filter = {size: LARGE}): String
+				}
+
+				input Filter {
+					size: Size = SMALL
+					tags: [String!] = ["a", "b"]
+					nested: Nested
+				}
+
+				input Nested {
+					value: Float = 1.5
+				}
+
+				enum Size {
+					SMALL
+					LARGE @deprecated
+				}
+			`,
+		},
+		{
+			Message: "directives and custom roots",
+			Schema: `
+				schema {
+					query: RootQuery
+					mutation: RootMutation
+				}
+
+				directive @tag(name: String!) repeatable on FIELD_DEFINITION | OBJECT
+
+				scalar DateTime @specifiedBy(url: "https://example.com/date-time")
+
+				type RootQuery {
+					now: DateTime
+				}
+
+				type RootMutation {
+					touch: DateTime
+				}
+			`,
+		},
+		{
+			Message: "one of and deprecated inputs",
+			Schema: `
+				directive @oneOf on INPUT_OBJECT
+
+				type Query {
+					find(by: By!, limit: Int @deprecated(reason: "use first"), first: Int): String
+				}
+
+				input By @oneOf {
+					id: ID
+					name: String
+					email: String @deprecated
+				}
+			`,
+		},
+	} {
+		row := row // enable parallel sub-tests
+		t.Run(row.Message, func(t *testing.T) {
+			t.Parallel()
+			schema, err := LoadSchema(row.Schema)
+			require.NoError(t, err)
+
+			// the features the executor supports are all detected
+			introspected, err := IntrospectAPI(NewSchemaIntrospectionQueryer(schema))
+			require.NoError(t, err)
+			assert.Equal(t, formatTestSchema(schema), formatTestSchema(introspected))
+
+			// the generated result should describe the same schema
+			generated, err := BuildSchemaFromIntrospection(NewIntrospectionQueryResult(schema))
+			require.NoError(t, err)
+			assert.Equal(t, formatTestSchema(schema), formatTestSchema(generated))
+		})
+	}
+}
+
+func TestExecuteIntrospectionQuery_introspectedSchema(t *testing.T) {
+	t.Parallel()
+	schema, err := IntrospectAPI(&mockJSONQueryer{JSONResult: introspectionInputFieldDefaultValuesJSON})
+	require.NoError(t, err)
+
+	introspected, err := IntrospectAPI(NewSchemaIntrospectionQueryer(schema))
+	require.NoError(t, err)
+	assert.Equal(t, formatTestSchema(schema), formatTestSchema(introspected))
+}
+
+func TestExecuteIntrospectionQuery_type(t *testing.T) {
+	t.Parallel()
+	schema, err := LoadSchema(`
+		type Query {
+			user: User
+		}
+
+		type User {
+			id: ID!
+			old: String @deprecated
+		}
+	`)
+	require.NoError(t, err)
+
+	data, err := ExecuteIntrospectionQuery(schema, &QueryInput{
+		Query: `
+			query ($name: String!, $all: Boolean!) {
+				__typename
+				user: __type(name: $name) {
+					kind
+					...Fields
+					fields(includeDeprecated: $all) @include(if: $all) {
+						isDeprecated
+					}
+				}
+				missing: __type(name: "Missing") {
+					name
+				}
+			}
+
+			fragment Fields on __Type {
+				fields(includeDeprecated: $all) {
+					name
+					type {
+						kind
+						ofType {
+							name
+						}
+					}
+				}
+			}
+		`,
+		Variables: map[string]interface{}{
+			"name": "User",
+			"all":  true,
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"__typename": "Query",
+		"user": map[string]interface{}{
+			"kind": "OBJECT",
+			"fields": []interface{}{
+				map[string]interface{}{
+					"name":         "id",
+					"isDeprecated": false,
+					"type": map[string]interface{}{
+						"kind":   "NON_NULL",
+						"ofType": map[string]interface{}{"name": "ID"},
+					},
+				},
+				map[string]interface{}{
+					"name":         "old",
+					"isDeprecated": true,
+					"type": map[string]interface{}{
+						"kind":   "SCALAR",
+						"ofType": nil,
+					},
+				},
+			},
+		},
+		"missing": nil,
+	}, data)
+}
+
+func TestExecuteIntrospectionQuery_errors(t *testing.T) {
+	t.Parallel()
+	schema, err := LoadSchema(`type Query { hello: String }`)
+	require.NoError(t, err)
+
+	for query, expected := range map[string]string{
+		`{ hello }`:                                     `cannot query field "hello" on type "Query"`,
+		`{ __schema { nope } }`:                         `cannot query field "nope" on type "__Schema"`,
+		`mutation { __typename }`:                       "cannot introspect with a mutation operation",
+		`{ __schema { ...Missing } }`:                   "could not find fragment definition: Missing",
+		`query A { __typename } query B { __typename }`: `could not find operation ""`,
+	} {
+		_, err := ExecuteIntrospectionQuery(schema, &QueryInput{Query: query})
+		assert.EqualError(t, err, expected, query)
+	}
+
+	var result map[string]interface{}
+	err = NewSchemaIntrospectionQueryer(schema).Query(context.Background(), &QueryInput{Query: "{"}, &result)
+	assert.True(t, strings.Contains(err.Error(), "Expected Name"), err.Error())
+}
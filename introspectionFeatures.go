@@ -0,0 +1,260 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// IntrospectionQuery is the query that is fired at an API to reconstruct its schema
+var IntrospectionQuery = introspectionQueryFeatures{}.query()
+
+// introspectionQueryFeatures describes the optional parts of the introspection query
+// which not every remote service supports
+type introspectionQueryFeatures struct {
+	// inputValueDeprecation asks for deprecated arguments and input fields
+	inputValueDeprecation bool
+	// schemaDescription asks for __Schema.description
+	schemaDescription bool
+	// directiveIsRepeatable asks for __Directive.isRepeatable
+	directiveIsRepeatable bool
+	// specifiedByURL is the name of the __Type field holding a scalar's specification URL.
+	// Older services spell it specifiedByUrl. Empty if the field is not supported.
+	specifiedByURL string
+	// oneOf asks for __Type.isOneOf
+	oneOf bool
+	// typeRefDepth is the number of levels in the TypeRef fragment. Zero means the default.
+	typeRefDepth int
+}
+
+// defaultTypeRefDepth is enough for any type reference with up to 3 lists, like [[[String!]!]!]!
+const defaultTypeRefDepth = 8
+
+// query builds the introspection query that asks for the enabled features
+func (f introspectionQueryFeatures) query() string {
+	inputValueArgs, inputValueFields := "", ""
+	if f.inputValueDeprecation {
+		inputValueArgs = "(includeDeprecated: true)"
+		inputValueFields = `
+		isDeprecated
+		deprecationReason`
+	}
+
+	schemaFields := ""
+	if f.schemaDescription {
+		schemaFields = `
+			description`
+	}
+
+	directiveFields := ""
+	if f.directiveIsRepeatable {
+		directiveFields = `
+				isRepeatable`
+	}
+
+	typeFields := ""
+	switch f.specifiedByURL {
+	case "":
+	case "specifiedByURL":
+		typeFields += `
+		specifiedByURL`
+	default:
+		// alias the legacy spelling so it decodes the same way
+		typeFields += `
+		specifiedByURL: ` + f.specifiedByURL
+	}
+	if f.oneOf {
+		typeFields += `
+		isOneOf`
+	}
+
+	depth := f.typeRefDepth
+	if depth <= 0 {
+		depth = defaultTypeRefDepth
+	}
+
+	return fmt.Sprintf(introspectionQueryTemplate, inputValueArgs, inputValueFields, schemaFields, directiveFields, typeFields) +
+		introspectionTypeRefFragment(depth)
+}
+
+// introspectionTypeRefFragment builds the TypeRef fragment with the given number of nested ofType levels
+func introspectionTypeRefFragment(depth int) string {
+	var b strings.Builder
+	b.WriteString("\n\tfragment TypeRef on __Type {")
+	for level := 0; level < depth; level++ {
+		indent := strings.Repeat("\t", level+2)
+		if level > 0 {
+			b.WriteString("\n" + strings.Repeat("\t", level+1) + "ofType {")
+		}
+		b.WriteString("\n" + indent + "kind\n" + indent + "name")
+	}
+	for level := depth - 1; level >= 0; level-- {
+		b.WriteString("\n" + strings.Repeat("\t", level+1) + "}")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// detectIntrospectionFeatures asks the remote service which parts of the introspection schema it supports
+// and returns the given features with every supported one turned on
+func detectIntrospectionFeatures(ctx context.Context, queryer Queryer, features introspectionQueryFeatures) (introspectionQueryFeatures, error) {
+	var result introspectionFeaturesResult
+	err := queryer.Query(ctx, &QueryInput{
+		Query:         introspectionFeaturesQuery,
+		OperationName: "IntrospectionFeatures",
+	}, &result)
+	if err != nil {
+		return features, err
+	}
+
+	if result.Schema.hasField("description") {
+		features.schemaDescription = true
+	}
+	if result.Directive.hasField("isRepeatable") {
+		features.directiveIsRepeatable = true
+	}
+	for _, name := range []string{"specifiedByURL", "specifiedByUrl"} {
+		if features.specifiedByURL == "" && result.Type.hasField(name) {
+			features.specifiedByURL = name
+		}
+	}
+	if result.Type.hasField("isOneOf") {
+		features.oneOf = true
+	}
+	if result.InputValue.hasField("isDeprecated") &&
+		result.Type.fieldHasArg("inputFields", "includeDeprecated") &&
+		result.Field.fieldHasArg("args", "includeDeprecated") &&
+		result.Directive.fieldHasArg("args", "includeDeprecated") {
+		features.inputValueDeprecation = true
+	}
+
+	return features, nil
+}
+
+// introspectionFeaturesQuery looks up the fields of the introspection types themselves
+const introspectionFeaturesQuery = `
+	query IntrospectionFeatures {
+		schema: __type(name: "__Schema") { ...FeatureFields }
+		type: __type(name: "__Type") { ...FeatureFields }
+		field: __type(name: "__Field") { ...FeatureFields }
+		directive: __type(name: "__Directive") { ...FeatureFields }
+		inputValue: __type(name: "__InputValue") { ...FeatureFields }
+	}
+
+	fragment FeatureFields on __Type {
+		fields {
+			name
+			args {
+				name
+			}
+		}
+	}
+`
+
+type introspectionFeaturesResult struct {
+	Schema     *introspectionFeaturesType `json:"schema"`
+	Type       *introspectionFeaturesType `json:"type"`
+	Field      *introspectionFeaturesType `json:"field"`
+	Directive  *introspectionFeaturesType `json:"directive"`
+	InputValue *introspectionFeaturesType `json:"inputValue"`
+}
+
+type introspectionFeaturesType struct {
+	Fields []struct {
+		Name string `json:"name"`
+		Args []struct {
+			Name string `json:"name"`
+		} `json:"args"`
+	} `json:"fields"`
+}
+
+func (t *introspectionFeaturesType) hasField(name string) bool {
+	return t.fieldHasArg(name, "")
+}
+
+// fieldHasArg returns true if the type has the named field with the named argument.
+// An empty argument name only checks for the field.
+func (t *introspectionFeaturesType) fieldHasArg(fieldName, argName string) bool {
+	if t == nil {
+		return false
+	}
+	for _, field := range t.Fields {
+		if field.Name != fieldName {
+			continue
+		}
+		if argName == "" {
+			return true
+		}
+		for _, arg := range field.Args {
+			if arg.Name == argName {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// introspectionQueryTemplate is filled in by introspectionQueryFeatures.query
+const introspectionQueryTemplate = `
+	query IntrospectionQuery {
+		__schema {%[3]s
+			queryType { name }
+			mutationType { name }
+			subscriptionType { name }
+			types {
+				...FullType
+			}
+			directives {
+				name
+				description%[4]s
+				locations
+				args%[1]s {
+				...InputValue
+				}
+			}
+		}
+	}
+
+	fragment FullType on __Type {
+		kind
+		name
+		description%[5]s
+		fields(includeDeprecated: true) {
+			name
+			description
+			args%[1]s {
+				...InputValue
+			}
+			type {
+				...TypeRef
+			}
+			isDeprecated
+			deprecationReason
+		}
+
+		inputFields%[1]s {
+			...InputValue
+		}
+
+		interfaces {
+			...TypeRef
+		}
+
+		enumValues(includeDeprecated: true) {
+			name
+			description
+			isDeprecated
+			deprecationReason
+		}
+		possibleTypes {
+			...TypeRef
+		}
+	}
+
+	fragment InputValue on __InputValue {
+		name
+		description
+		type { ...TypeRef }
+		defaultValue%[2]s
+	}
+`
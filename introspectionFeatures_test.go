@@ -0,0 +1,236 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/formatter"
+)
+
+// mockOperationQueryer unmarshals the JSON result registered for the input's operation name into the receiver
+type mockOperationQueryer struct {
+	JSONResults map[string]string
+	Queries     []string
+}
+
+func (q *mockOperationQueryer) Query(ctx context.Context, input *QueryInput, receiver interface{}) error {
+	q.Queries = append(q.Queries, input.Query)
+	result, ok := q.JSONResults[input.OperationName]
+	if !ok {
+		return NewError("GRAPHQL_VALIDATION_FAILED", "unknown operation "+input.OperationName)
+	}
+	return json.Unmarshal([]byte(result), receiver)
+}
+
+func TestIntrospectionQueryFeatures_valid(t *testing.T) {
+	t.Parallel()
+	// gqlparser's prelude supports everything but @oneOf and deprecated input values
+	schema, err := LoadSchema(`
+		type Query {
+			hello: String
+		}
+	`)
+	require.NoError(t, err)
+
+	for name, query := range map[string]string{
+		"default":  IntrospectionQuery,
+		"features": introspectionFeaturesQuery,
+		"extended": introspectionQueryFeatures{
+			schemaDescription:     true,
+			directiveIsRepeatable: true,
+			specifiedByURL:        "specifiedByURL",
+		}.query(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, errs := gqlparser.LoadQuery(schema, query)
+			assert.Empty(t, errs)
+		})
+	}
+}
+
+func TestIntrospectionQueryFeatures_legacySpecifiedByURL(t *testing.T) {
+	t.Parallel()
+	query := introspectionQueryFeatures{specifiedByURL: "specifiedByUrl"}.query()
+	assert.Contains(t, query, "specifiedByURL: specifiedByUrl")
+}
+
+func TestIntrospectAPI_featureDetection(t *testing.T) {
+	t.Parallel()
+	queryer := &mockOperationQueryer{
+		JSONResults: map[string]string{
+			"IntrospectionFeatures": `{
+				"schema": {"fields": [{"name": "description", "args": []}, {"name": "types", "args": []}]},
+				"type": {"fields": [
+					{"name": "specifiedByUrl", "args": []},
+					{"name": "isOneOf", "args": []},
+					{"name": "inputFields", "args": [{"name": "includeDeprecated"}]}
+				]},
+				"field": {"fields": [{"name": "args", "args": [{"name": "includeDeprecated"}]}]},
+				"directive": {"fields": [
+					{"name": "isRepeatable", "args": []},
+					{"name": "args", "args": [{"name": "includeDeprecated"}]}
+				]},
+				"inputValue": {"fields": [{"name": "isDeprecated", "args": []}]}
+			}`,
+			"IntrospectionQuery": `{
+				"__schema": {
+					"description": "The schema.",
+					"queryType": {"name": "Query"},
+					"types": [
+						{
+							"kind": "OBJECT",
+							"name": "Query",
+							"fields": [
+								{
+									"name": "hello",
+									"args": [
+										{"name": "input", "type": {"kind": "INPUT_OBJECT", "name": "HelloInput"}},
+										{"name": "at", "type": {"kind": "SCALAR", "name": "DateTime"}}
+									],
+									"type": {"kind": "SCALAR", "name": "String"}
+								}
+							]
+						},
+						{
+							"kind": "INPUT_OBJECT",
+							"name": "HelloInput",
+							"isOneOf": true,
+							"inputFields": [
+								{"name": "a", "type": {"kind": "SCALAR", "name": "String"}},
+								{"name": "b", "type": {"kind": "SCALAR", "name": "String"}}
+							]
+						},
+						{
+							"kind": "SCALAR",
+							"name": "DateTime",
+							"specifiedByURL": "https://scalars.graphql.org/andimarek/date-time"
+						}
+					],
+					"directives": [
+						{
+							"name": "tag",
+							"locations": ["FIELD_DEFINITION"],
+							"isRepeatable": true,
+							"args": [{"name": "name", "type": {"kind": "SCALAR", "name": "String"}}]
+						},
+						{
+							"name": "specifiedBy",
+							"locations": ["SCALAR"],
+							"args": [{"name": "url", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "String"}}}]
+						}
+					]
+				}
+			}`,
+		},
+	}
+
+	schema, err := IntrospectAPI(queryer)
+	require.NoError(t, err)
+
+	require.Len(t, queryer.Queries, 2)
+	query := queryer.Queries[1]
+	assert.Contains(t, query, "specifiedByURL: specifiedByUrl")
+	assert.Contains(t, query, "isOneOf")
+	assert.Contains(t, query, "isRepeatable")
+	assert.Contains(t, query, "inputFields(includeDeprecated: true)")
+
+	assert.Equal(t, "The schema.", schema.Description)
+	assert.True(t, schema.Directives["tag"].IsRepeatable)
+	assert.Equal(t, schema.Directives["oneOf"], schema.Types["HelloInput"].Directives.ForName("oneOf").Definition)
+
+	var schemaBuffer bytes.Buffer
+	formatter.NewFormatter(&schemaBuffer).FormatSchema(schema)
+
+	expectedSchema, err := gqlparser.LoadSchema(&ast.Source{Input: `
+"""The schema."""
+schema {
+  query: Query
+}
+
+directive @tag(name: String) repeatable on FIELD_DEFINITION
+
+"""Indicates exactly one field must be supplied and this field must not be ` + "`null`" + `."""
+directive @oneOf on INPUT_OBJECT
+
+type Query {
+  hello(input: HelloInput, at: DateTime): String
+}
+
+input HelloInput @oneOf {
+  a: String
+  b: String
+}
+
+scalar DateTime @specifiedBy(url: "https://scalars.graphql.org/andimarek/date-time")
+	`})
+	require.NoError(t, err)
+	var expectedBuffer bytes.Buffer
+	formatter.NewFormatter(&expectedBuffer).FormatSchema(expectedSchema)
+	assert.Equal(t, expectedBuffer.String(), schemaBuffer.String())
+}
+
+func TestIntrospectAPI_featureDetectionFallback(t *testing.T) {
+	t.Parallel()
+	queryer := &mockOperationQueryer{
+		JSONResults: map[string]string{
+			"IntrospectionQuery": `{
+				"__schema": {
+					"queryType": {"name": "Query"}
+				}
+			}`,
+		},
+	}
+
+	_, err := IntrospectAPI(queryer)
+	require.NoError(t, err)
+	require.Len(t, queryer.Queries, 2)
+	assert.Equal(t, IntrospectionQuery, queryer.Queries[1])
+}
+
+func TestIntrospectAPI_withoutFeatureDetection(t *testing.T) {
+	t.Parallel()
+	queryer := &mockOperationQueryer{
+		JSONResults: map[string]string{
+			"IntrospectionQuery": `{
+				"__schema": {
+					"queryType": {"name": "Query"}
+				}
+			}`,
+		},
+	}
+
+	_, err := IntrospectAPI(queryer, IntrospectWithoutFeatureDetection())
+	require.NoError(t, err)
+	assert.Equal(t, []string{IntrospectionQuery}, queryer.Queries)
+}
+
+func TestIntrospectAPI_featureDetectionDiff(t *testing.T) {
+	t.Parallel()
+	schema, err := LoadSchema(`
+		directive @tag(name: String!) repeatable on FIELD_DEFINITION
+		directive @oneOf on INPUT_OBJECT
+
+		scalar Date @specifiedBy(url: "https://example.com/date")
+
+		input By @oneOf {
+			id: ID
+			name: String
+		}
+
+		type Query {
+			find(by: By!): Date @tag(name: "a") @tag(name: "b")
+		}
+	`)
+	require.NoError(t, err)
+
+	// the introspected schema has nothing the default introspection loses
+	introspected, err := IntrospectAPI(NewSchemaIntrospectionQueryer(schema))
+	require.NoError(t, err)
+	assert.Empty(t, DiffSchemas(schema, introspected))
+}
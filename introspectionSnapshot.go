@@ -0,0 +1,363 @@
+package graphql
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// NewIntrospectionQueryResult builds the result of the introspection query for the given schema
+func NewIntrospectionQueryResult(schema *ast.Schema) *IntrospectionQueryResult {
+	result := &IntrospectionQuerySchema{
+		Description: schema.Description,
+		Types:       []IntrospectionQueryFullType{},
+		Directives:  []IntrospectionQueryDirective{},
+	}
+
+	// the root types
+	if schema.Query != nil {
+		result.QueryType = IntrospectionQueryRootType{Name: schema.Query.Name}
+	}
+	if schema.Mutation != nil {
+		result.MutationType = &IntrospectionQueryRootType{Name: schema.Mutation.Name}
+	}
+	if schema.Subscription != nil {
+		result.SubscriptionType = &IntrospectionQueryRootType{Name: schema.Subscription.Name}
+	}
+
+	// sort the types and directives so the result is stable
+	typeNames := make([]string, 0, len(schema.Types))
+	for name := range schema.Types {
+		typeNames = append(typeNames, name)
+	}
+	sort.Strings(typeNames)
+	for _, name := range typeNames {
+		result.Types = append(result.Types, introspectionMarshalType(schema, schema.Types[name]))
+	}
+
+	directiveNames := make([]string, 0, len(schema.Directives))
+	for name := range schema.Directives {
+		directiveNames = append(directiveNames, name)
+	}
+	sort.Strings(directiveNames)
+	for _, name := range directiveNames {
+		directive := schema.Directives[name]
+
+		locations := []string{}
+		for _, location := range directive.Locations {
+			locations = append(locations, string(location))
+		}
+
+		result.Directives = append(result.Directives, IntrospectionQueryDirective{
+			Name:         directive.Name,
+			Description:  directive.Description,
+			Locations:    locations,
+			Args:         introspectionMarshalArgList(schema, directive.Arguments),
+			IsRepeatable: directive.IsRepeatable,
+		})
+	}
+
+	return &IntrospectionQueryResult{Schema: result}
+}
+
+func introspectionMarshalType(schema *ast.Schema, definition *ast.Definition) IntrospectionQueryFullType {
+	result := IntrospectionQueryFullType{
+		Kind:        string(definition.Kind),
+		Name:        definition.Name,
+		Description: definition.Description,
+	}
+
+	switch definition.Kind {
+	case ast.Object, ast.Interface:
+		// the spec requires both lists for objects and interfaces
+		result.Fields = []IntrospectionQueryFullTypeField{}
+		result.Interfaces = []IntrospectionTypeRef{}
+
+		for _, field := range definition.Fields {
+			// meta fields like __schema and __type are not part of the type
+			if strings.HasPrefix(field.Name, "__") {
+				continue
+			}
+
+			isDeprecated, reason := introspectionMarshalDeprecation(field.Directives)
+			result.Fields = append(result.Fields, IntrospectionQueryFullTypeField{
+				Name:              field.Name,
+				Description:       field.Description,
+				Args:              introspectionMarshalArgList(schema, field.Arguments),
+				Type:              *introspectionMarshalTypeRef(schema, field.Type),
+				IsDeprecated:      isDeprecated,
+				DeprecationReason: reason,
+			})
+		}
+
+		for _, name := range definition.Interfaces {
+			result.Interfaces = append(result.Interfaces, IntrospectionTypeRef{Kind: string(ast.Interface), Name: name})
+		}
+
+		// interfaces list the objects that implement them
+		if definition.Kind == ast.Interface {
+			result.PossibleTypes = []IntrospectionTypeRef{}
+			for _, possibleType := range schema.GetPossibleTypes(definition) {
+				if possibleType.Kind == ast.Object {
+					result.PossibleTypes = append(result.PossibleTypes, IntrospectionTypeRef{Kind: string(ast.Object), Name: possibleType.Name})
+				}
+			}
+		}
+
+	case ast.Union:
+		result.PossibleTypes = []IntrospectionTypeRef{}
+		for _, name := range definition.Types {
+			result.PossibleTypes = append(result.PossibleTypes, IntrospectionTypeRef{Kind: string(ast.Object), Name: name})
+		}
+
+	case ast.Enum:
+		result.EnumValues = []IntrospectionQueryEnumDefinition{}
+		for _, value := range definition.EnumValues {
+			isDeprecated, reason := introspectionMarshalDeprecation(value.Directives)
+			result.EnumValues = append(result.EnumValues, IntrospectionQueryEnumDefinition{
+				Name:              value.Name,
+				Description:       value.Description,
+				IsDeprecated:      isDeprecated,
+				DeprecationReason: reason,
+			})
+		}
+
+	case ast.InputObject:
+		result.InputFields = []IntrospectionInputValue{}
+		for _, field := range definition.Fields {
+			result.InputFields = append(result.InputFields, introspectionMarshalInputValue(schema, field.Name, field.Description, field.Type, field.DefaultValue, field.Directives))
+		}
+		result.IsOneOf = definition.Directives.ForName("oneOf") != nil
+
+	case ast.Scalar:
+		if specifiedBy := definition.Directives.ForName("specifiedBy"); specifiedBy != nil {
+			if url := specifiedBy.Arguments.ForName("url"); url != nil && url.Value != nil {
+				result.SpecifiedByURL = url.Value.Raw
+			}
+		}
+	}
+
+	return result
+}
+
+func introspectionMarshalArgList(schema *ast.Schema, args ast.ArgumentDefinitionList) []IntrospectionInputValue {
+	result := []IntrospectionInputValue{}
+	for _, arg := range args {
+		result = append(result, introspectionMarshalInputValue(schema, arg.Name, arg.Description, arg.Type, arg.DefaultValue, arg.Directives))
+	}
+	return result
+}
+
+func introspectionMarshalInputValue(schema *ast.Schema, name, description string, typ *ast.Type, defaultValue *ast.Value, directives ast.DirectiveList) IntrospectionInputValue {
+	isDeprecated, reason := introspectionMarshalDeprecation(directives)
+	result := IntrospectionInputValue{
+		Name:              name,
+		Description:       description,
+		Type:              *introspectionMarshalTypeRef(schema, typ),
+		IsDeprecated:      isDeprecated,
+		DeprecationReason: reason,
+	}
+	if defaultValue != nil {
+		result.DefaultValue = defaultValue.String()
+	}
+	return result
+}
+
+// introspectionMarshalDeprecation looks for a @deprecated directive and its reason
+func introspectionMarshalDeprecation(directives ast.DirectiveList) (bool, string) {
+	deprecated := directives.ForName("deprecated")
+	if deprecated == nil {
+		return false, ""
+	}
+	if reason := deprecated.Arguments.ForName("reason"); reason != nil && reason.Value != nil {
+		return true, reason.Value.Raw
+	}
+	return true, ""
+}
+
+// introspectionMarshalTypeRef converts a type into its nested introspection form
+func introspectionMarshalTypeRef(schema *ast.Schema, typ *ast.Type) *IntrospectionTypeRef {
+	if typ.NonNull {
+		nullable := *typ
+		nullable.NonNull = false
+		return &IntrospectionTypeRef{Kind: "NON_NULL", OfType: introspectionMarshalTypeRef(schema, &nullable)}
+	}
+	if typ.Elem != nil {
+		return &IntrospectionTypeRef{Kind: "LIST", OfType: introspectionMarshalTypeRef(schema, typ.Elem)}
+	}
+
+	result := &IntrospectionTypeRef{Name: typ.NamedType}
+	if definition, ok := schema.Types[typ.NamedType]; ok {
+		result.Kind = string(definition.Kind)
+	}
+	return result
+}
+
+// WriteIntrospectionQueryResult writes the result to w in the standard introspection JSON format
+func WriteIntrospectionQueryResult(w io.Writer, result *IntrospectionQueryResult) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "\t")
+	return encoder.Encode(result)
+}
+
+// WriteRemoteSchema writes a snapshot of the remote schema to w in the standard introspection JSON format
+func WriteRemoteSchema(w io.Writer, schema *RemoteSchema) error {
+	return WriteIntrospectionQueryResult(w, NewIntrospectionQueryResult(schema.Schema))
+}
+
+// ReadIntrospectionQueryResult reads an introspection result in the standard JSON format from r.
+// The result can also be wrapped in a {"data": ...} response envelope, like the ones produced by other tools.
+func ReadIntrospectionQueryResult(r io.Reader) (*IntrospectionQueryResult, error) {
+	var envelope struct {
+		IntrospectionQueryResult
+		Data   *IntrospectionQueryResult `json:"data"`
+		Errors []*Error                  `json:"errors"`
+	}
+	if err := json.NewDecoder(r).Decode(&envelope); err != nil {
+		return nil, errors.WithMessage(err, "could not decode introspection result")
+	}
+
+	// a response that failed is not a snapshot
+	if len(envelope.Errors) > 0 {
+		errList := ErrorList{}
+		for _, err := range envelope.Errors {
+			errList = append(errList, err)
+		}
+		return nil, errList
+	}
+
+	result := &envelope.IntrospectionQueryResult
+	if envelope.Data != nil {
+		result = envelope.Data
+	}
+	if result.Schema == nil {
+		return nil, errors.New("could not find __schema in introspection result")
+	}
+
+	return result, nil
+}
+
+// ReadRemoteSchema reads a snapshot written by WriteRemoteSchema, or any other introspection result,
+// and returns the RemoteSchema it describes for the given url
+func ReadRemoteSchema(url string, r io.Reader) (*RemoteSchema, error) {
+	result, err := ReadIntrospectionQueryResult(r)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := BuildSchemaFromIntrospection(result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RemoteSchema{
+		URL:    url,
+		Schema: schema,
+	}, nil
+}
+
+// nullableString returns nil for empty strings, which the standard introspection format represents as null
+func nullableString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// MarshalJSON writes the schema with null for missing optional values
+func (s IntrospectionQuerySchema) MarshalJSON() ([]byte, error) {
+	type schema IntrospectionQuerySchema
+	return json.Marshal(struct {
+		schema
+		Description *string `json:"description"`
+	}{
+		schema:      schema(s),
+		Description: nullableString(s.Description),
+	})
+}
+
+// MarshalJSON writes the directive with null for missing optional values
+func (d IntrospectionQueryDirective) MarshalJSON() ([]byte, error) {
+	type directive IntrospectionQueryDirective
+	return json.Marshal(struct {
+		directive
+		Description *string `json:"description"`
+	}{
+		directive:   directive(d),
+		Description: nullableString(d.Description),
+	})
+}
+
+// MarshalJSON writes the type with null for missing optional values
+func (t IntrospectionQueryFullType) MarshalJSON() ([]byte, error) {
+	type fullType IntrospectionQueryFullType
+	return json.Marshal(struct {
+		fullType
+		Description    *string `json:"description"`
+		SpecifiedByURL *string `json:"specifiedByURL"`
+	}{
+		fullType:       fullType(t),
+		Description:    nullableString(t.Description),
+		SpecifiedByURL: nullableString(t.SpecifiedByURL),
+	})
+}
+
+// MarshalJSON writes the field with null for missing optional values
+func (f IntrospectionQueryFullTypeField) MarshalJSON() ([]byte, error) {
+	type field IntrospectionQueryFullTypeField
+	return json.Marshal(struct {
+		field
+		Description       *string `json:"description"`
+		DeprecationReason *string `json:"deprecationReason"`
+	}{
+		field:             field(f),
+		Description:       nullableString(f.Description),
+		DeprecationReason: nullableString(f.DeprecationReason),
+	})
+}
+
+// MarshalJSON writes the enum value with null for missing optional values
+func (e IntrospectionQueryEnumDefinition) MarshalJSON() ([]byte, error) {
+	type enumValue IntrospectionQueryEnumDefinition
+	return json.Marshal(struct {
+		enumValue
+		Description       *string `json:"description"`
+		DeprecationReason *string `json:"deprecationReason"`
+	}{
+		enumValue:         enumValue(e),
+		Description:       nullableString(e.Description),
+		DeprecationReason: nullableString(e.DeprecationReason),
+	})
+}
+
+// MarshalJSON writes the input value with null for missing optional values
+func (v IntrospectionInputValue) MarshalJSON() ([]byte, error) {
+	type inputValue IntrospectionInputValue
+	return json.Marshal(struct {
+		inputValue
+		Description       *string `json:"description"`
+		DefaultValue      *string `json:"defaultValue"`
+		DeprecationReason *string `json:"deprecationReason"`
+	}{
+		inputValue:        inputValue(v),
+		Description:       nullableString(v.Description),
+		DefaultValue:      nullableString(v.DefaultValue),
+		DeprecationReason: nullableString(v.DeprecationReason),
+	})
+}
+
+// MarshalJSON writes the type reference with null for the name of wrapping types
+func (r IntrospectionTypeRef) MarshalJSON() ([]byte, error) {
+	type typeRef IntrospectionTypeRef
+	return json.Marshal(struct {
+		typeRef
+		Name *string `json:"name"`
+	}{
+		typeRef: typeRef(r),
+		Name:    nullableString(r.Name),
+	})
+}
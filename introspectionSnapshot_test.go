@@ -0,0 +1,141 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/formatter"
+)
+
+func formatTestSchema(schema *ast.Schema) string {
+	var buf bytes.Buffer
+	formatter.NewFormatter(&buf).FormatSchema(schema)
+	return buf.String()
+}
+
+func TestReadIntrospectionQueryResult(t *testing.T) {
+	t.Parallel()
+	for _, row := range []struct {
+		Message string
+		JSON    string
+		Error   string
+	}{
+		{
+			Message: "bare result",
+			JSON:    `{"__schema": {"queryType": {"name": "Query"}}}`,
+		},
+		{
+			Message: "response envelope",
+			JSON:    `{"data": {"__schema": {"queryType": {"name": "Query"}}}}`,
+		},
+		{
+			Message: "response errors",
+			JSON:    `{"data": null, "errors": [{"message": "introspection is disabled"}]}`,
+			Error:   "introspection is disabled",
+		},
+		{
+			Message: "missing schema",
+			JSON:    `{"data": {}}`,
+			Error:   "could not find __schema in introspection result",
+		},
+		{
+			Message: "invalid json",
+			JSON:    `{`,
+			Error:   "could not decode introspection result: unexpected EOF",
+		},
+	} {
+		row := row // enable parallel sub-tests
+		t.Run(row.Message, func(t *testing.T) {
+			t.Parallel()
+			result, err := ReadIntrospectionQueryResult(strings.NewReader(row.JSON))
+			if row.Error != "" {
+				assert.EqualError(t, err, row.Error)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "Query", result.Schema.QueryType.Name)
+		})
+	}
+}
+
+func TestRemoteSchemaSnapshot_roundTrip(t *testing.T) {
+	t.Parallel()
+	original, err := ReadRemoteSchema("http://service", strings.NewReader(introspectionInputFieldDefaultValuesJSON))
+	require.NoError(t, err)
+	assert.Equal(t, "http://service", original.URL)
+
+	var snapshot bytes.Buffer
+	require.NoError(t, WriteRemoteSchema(&snapshot, original))
+
+	loaded, err := ReadRemoteSchema("http://service", &snapshot)
+	require.NoError(t, err)
+	assert.Equal(t, formatTestSchema(original.Schema), formatTestSchema(loaded.Schema))
+}
+
+func TestWriteIntrospectionQueryResult_nulls(t *testing.T) {
+	t.Parallel()
+	schema, err := LoadSchema(`
+		type Query {
+			hello(name: String): [String!]
+		}
+	`)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteIntrospectionQueryResult(&buf, NewIntrospectionQueryResult(schema)))
+
+	var result struct {
+		Schema struct {
+			Types []map[string]interface{} `json:"types"`
+		} `json:"__schema"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &result))
+
+	var query map[string]interface{}
+	for _, typ := range result.Schema.Types {
+		if typ["name"] == "Query" {
+			query = typ
+		}
+	}
+	require.NotNil(t, query)
+	assert.Nil(t, query["description"])
+	assert.Nil(t, query["inputFields"])
+	assert.Equal(t, []interface{}{}, query["interfaces"])
+
+	fields := query["fields"].([]interface{})
+	require.Len(t, fields, 1)
+	assert.Equal(t, map[string]interface{}{
+		"name":              "hello",
+		"description":       nil,
+		"isDeprecated":      false,
+		"deprecationReason": nil,
+		"args": []interface{}{
+			map[string]interface{}{
+				"name":              "name",
+				"description":       nil,
+				"defaultValue":      nil,
+				"isDeprecated":      false,
+				"deprecationReason": nil,
+				"type":              map[string]interface{}{"kind": "SCALAR", "name": "String", "ofType": nil},
+			},
+		},
+		"type": map[string]interface{}{
+			"kind": "LIST",
+			"name": nil,
+			"ofType": map[string]interface{}{
+				"kind": "NON_NULL",
+				"name": nil,
+				"ofType": map[string]interface{}{
+					"kind":   "SCALAR",
+					"name":   "String",
+					"ofType": nil,
+				},
+			},
+		},
+	}, fields[0])
+}
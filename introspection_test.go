@@ -8,7 +8,11 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -813,11 +817,122 @@ func TestIntrospectQueryUnmarshalType_enums(t *testing.T) {
 }
 
 func TestIntrospectQuery_deprecatedFields(t *testing.T) {
-	t.Skip("Not yet implemented")
+	t.Parallel()
+	schema, err := IntrospectAPI(&MockSuccessQueryer{
+		IntrospectionQueryResult{
+			Schema: &IntrospectionQuerySchema{
+				QueryType: IntrospectionQueryRootType{
+					Name: "Query",
+				},
+				Types: []IntrospectionQueryFullType{
+					{
+						Kind: "OBJECT",
+						Name: "Query",
+						Fields: []IntrospectionQueryFullTypeField{
+							{
+								Name:              "old",
+								Type:              IntrospectionTypeRef{Kind: "SCALAR", Name: "String"},
+								IsDeprecated:      true,
+								DeprecationReason: "use new",
+							},
+							{
+								Name: "new",
+								Type: IntrospectionTypeRef{Kind: "SCALAR", Name: "String"},
+							},
+						},
+					},
+				},
+				Directives: []IntrospectionQueryDirective{
+					{
+						Name:      "deprecated",
+						Locations: []string{"FIELD_DEFINITION", "ENUM_VALUE"},
+						Args: []IntrospectionInputValue{
+							{
+								Name:         "reason",
+								Type:         IntrospectionTypeRef{Kind: "SCALAR", Name: "String"},
+								DefaultValue: `"No longer supported"`,
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	query := schema.Types["Query"]
+	assert.Empty(t, query.Fields.ForName("new").Directives)
+
+	deprecated := query.Fields.ForName("old").Directives.ForName("deprecated")
+	require.NotNil(t, deprecated)
+	assert.Equal(t, schema.Directives["deprecated"], deprecated.Definition)
+	reason := deprecated.Arguments.ForName("reason")
+	require.NotNil(t, reason)
+	assert.Equal(t, ast.StringValue, reason.Value.Kind)
+	assert.Equal(t, "use new", reason.Value.Raw)
 }
 
 func TestIntrospectQuery_deprecatedEnums(t *testing.T) {
-	t.Skip("Not yet Implemented")
+	t.Parallel()
+	schema, err := IntrospectAPI(&MockSuccessQueryer{
+		IntrospectionQueryResult{
+			Schema: &IntrospectionQuerySchema{
+				QueryType: IntrospectionQueryRootType{
+					Name: "Query",
+				},
+				Types: []IntrospectionQueryFullType{
+					{
+						Kind: "OBJECT",
+						Name: "Query",
+						Fields: []IntrospectionQueryFullTypeField{
+							{
+								Name: "word",
+								Type: IntrospectionTypeRef{Kind: "ENUM", Name: "Word"},
+							},
+						},
+					},
+					{
+						Kind: "ENUM",
+						Name: "Word",
+						EnumValues: []IntrospectionQueryEnumDefinition{
+							{
+								Name:              "hello",
+								IsDeprecated:      true,
+								DeprecationReason: "say hi",
+							},
+							{
+								Name:         "hi",
+								IsDeprecated: true,
+							},
+							{
+								Name: "goodbye",
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	var schemaBuffer bytes.Buffer
+	formatter.NewFormatter(&schemaBuffer).FormatSchema(schema)
+
+	expectedSchema, err := gqlparser.LoadSchema(&ast.Source{Input: `
+type Query {
+  word: Word
+}
+
+enum Word {
+  hello @deprecated(reason: "say hi")
+  hi @deprecated
+  goodbye
+}
+	`})
+	require.NoError(t, err)
+	var expectedBuffer bytes.Buffer
+	formatter.NewFormatter(&expectedBuffer).FormatSchema(expectedSchema)
+	assert.Equal(t, expectedBuffer.String(), schemaBuffer.String())
 }
 
 func TestIntrospectQueryUnmarshalType_inputObjects(t *testing.T) {
@@ -983,11 +1098,107 @@ func TestIntrospectUnmarshalTypeDef(t *testing.T) {
 
 	for _, row := range table {
 		t.Run(row.Message, func(t *testing.T) {
-			assert.Equal(t, row.Expected, introspectionUnmarshalTypeRef(row.RemoteType), fmt.Sprintf("Desired type: %s", row.Message))
+			typ, err := introspectionUnmarshalTypeRef(row.RemoteType)
+			require.NoError(t, err)
+			assert.Equal(t, row.Expected, typ, fmt.Sprintf("Desired type: %s", row.Message))
+		})
+	}
+}
+
+func TestIntrospectUnmarshalTypeDef_anyNesting(t *testing.T) {
+	t.Parallel()
+	for _, typeString := range []string{
+		"[[User!]]",
+		"[[[User!]!]!]!",
+		"[[[[User]]]]!",
+	} {
+		t.Run(typeString, func(t *testing.T) {
+			schema, err := LoadSchema(fmt.Sprintf("type User { id: ID }\ntype Query { user: %s }", typeString))
+			require.NoError(t, err)
+
+			typ, err := introspectionUnmarshalTypeRef(introspectionTestTypeRef(schema.Query.Fields.ForName("user").Type))
+			require.NoError(t, err)
+			assert.Equal(t, typeString, typ.String())
 		})
 	}
 }
 
+func TestIntrospectUnmarshalTypeDef_truncated(t *testing.T) {
+	t.Parallel()
+	_, err := introspectionUnmarshalTypeRef(&IntrospectionTypeRef{
+		Kind: "NON_NULL",
+		OfType: &IntrospectionTypeRef{
+			Kind: "LIST",
+		},
+	})
+	assert.Equal(t, errTruncatedTypeRef, err)
+}
+
+// introspectionTestTypeRef converts a type into its introspection form, like a remote service would
+func introspectionTestTypeRef(typ *ast.Type) *IntrospectionTypeRef {
+	if typ.NonNull {
+		nullable := *typ
+		nullable.NonNull = false
+		return &IntrospectionTypeRef{Kind: "NON_NULL", OfType: introspectionTestTypeRef(&nullable)}
+	}
+	if typ.Elem != nil {
+		return &IntrospectionTypeRef{Kind: "LIST", OfType: introspectionTestTypeRef(typ.Elem)}
+	}
+	return &IntrospectionTypeRef{Kind: "OBJECT", Name: typ.NamedType}
+}
+
+// truncateTypeRef cuts off a type reference after the given number of levels, like a query with a shallow TypeRef fragment would
+func truncateTypeRef(ref *IntrospectionTypeRef, depth int) *IntrospectionTypeRef {
+	if ref == nil {
+		return nil
+	}
+	truncated := *ref
+	if depth <= 1 {
+		truncated.OfType = nil
+	} else {
+		truncated.OfType = truncateTypeRef(ref.OfType, depth-1)
+	}
+	return &truncated
+}
+
+func TestIntrospectAPI_typeRefDepth(t *testing.T) {
+	t.Parallel()
+	schema, err := LoadSchema("type Query { deep: [[[[String!]!]!]!]! }")
+	require.NoError(t, err)
+	deepType := introspectionTestTypeRef(schema.Query.Fields.ForName("deep").Type)
+
+	// respond like a remote service would, cutting the type reference to the depth of the TypeRef fragment
+	queryer := QueryerFunc(func(input *QueryInput) (interface{}, error) {
+		// the service doesn't tell which features it supports
+		if input.OperationName != "IntrospectionQuery" {
+			return nil, errors.New("unknown operation")
+		}
+		depth := strings.Count(input.Query[strings.Index(input.Query, "fragment TypeRef"):], "kind")
+		return IntrospectionQueryResult{
+			Schema: &IntrospectionQuerySchema{
+				QueryType: IntrospectionQueryRootType{Name: "Query"},
+				Types: []IntrospectionQueryFullType{
+					{
+						Kind: "OBJECT",
+						Name: "Query",
+						Fields: []IntrospectionQueryFullTypeField{
+							{Name: "deep", Type: *truncateTypeRef(deepType, depth)},
+						},
+					},
+				},
+			},
+		}, nil
+	})
+
+	_, err = IntrospectAPI(queryer)
+	assert.ErrorIs(t, err, errTruncatedTypeRef)
+	assert.Contains(t, err.Error(), "field Query.deep")
+
+	introspected, err := IntrospectAPI(queryer, IntrospectWithTypeRefDepth(10))
+	require.NoError(t, err)
+	assert.Equal(t, "[[[[String!]!]!]!]!", introspected.Query.Fields.ForName("deep").Type.String())
+}
+
 func TestIntrospectWithContext(t *testing.T) {
 	table := []struct {
 		Message string
@@ -1201,7 +1412,8 @@ func TestIntrospectAPI_retry(t *testing.T) {
 	t.Run("no retrier", func(t *testing.T) {
 		t.Parallel()
 		queryer := makeQueryer()
-		queryer.FailuresRemaining = 1
+		// the feature detection fails first, which falls back to the standard query
+		queryer.FailuresRemaining = 2
 		_, err := IntrospectAPI(queryer)
 		assert.Zero(t, queryer.FailuresRemaining)
 		require.EqualError(t, err, "query failed: foo")
@@ -1560,3 +1772,316 @@ enum HelloEnum {
 	formatter.NewFormatter(&expectedBuffer).FormatSchema(expectedSchema)
 	assert.Equal(t, expectedBuffer.String(), schemaBuffer.String())
 }
+
+func TestIntrospectAPI_inputValueDeprecation(t *testing.T) {
+	t.Parallel()
+	var queries []string
+	queryer := QueryerFunc(func(input *QueryInput) (interface{}, error) {
+		queries = append(queries, input.Query)
+		return IntrospectionQueryResult{
+			Schema: &IntrospectionQuerySchema{
+				QueryType: IntrospectionQueryRootType{
+					Name: "Query",
+				},
+				Types: []IntrospectionQueryFullType{
+					{
+						Kind: "OBJECT",
+						Name: "Query",
+						Fields: []IntrospectionQueryFullTypeField{
+							{
+								Name: "hello",
+								Type: IntrospectionTypeRef{Kind: "SCALAR", Name: "String"},
+								Args: []IntrospectionInputValue{
+									{
+										Name:              "name",
+										Type:              IntrospectionTypeRef{Kind: "SCALAR", Name: "String"},
+										IsDeprecated:      true,
+										DeprecationReason: "use input",
+									},
+									{
+										Name: "input",
+										Type: IntrospectionTypeRef{Kind: "INPUT_OBJECT", Name: "HelloInput"},
+									},
+								},
+							},
+						},
+					},
+					{
+						Kind: "INPUT_OBJECT",
+						Name: "HelloInput",
+						InputFields: []IntrospectionInputValue{
+							{
+								Name:         "name",
+								Type:         IntrospectionTypeRef{Kind: "SCALAR", Name: "String"},
+								IsDeprecated: true,
+							},
+						},
+					},
+				},
+			},
+		}, nil
+	})
+
+	t.Run("default query", func(t *testing.T) {
+		_, err := IntrospectAPI(queryer)
+		require.NoError(t, err)
+		assert.NotContains(t, queries[len(queries)-1], "args(includeDeprecated: true)")
+		assert.NotContains(t, queries[len(queries)-1], "inputFields(includeDeprecated: true)")
+	})
+
+	t.Run("with input value deprecation", func(t *testing.T) {
+		schema, err := IntrospectAPI(queryer, IntrospectWithInputValueDeprecation())
+		require.NoError(t, err)
+		assert.Contains(t, queries[len(queries)-1], "args(includeDeprecated: true)")
+		assert.Contains(t, queries[len(queries)-1], "inputFields(includeDeprecated: true)")
+
+		var schemaBuffer bytes.Buffer
+		formatter.NewFormatter(&schemaBuffer).FormatSchema(schema)
+
+		expectedSchema, err := gqlparser.LoadSchema(&ast.Source{Input: `
+type Query {
+  hello(name: String @deprecated(reason: "use input"), input: HelloInput): String
+}
+
+input HelloInput {
+  name: String @deprecated
+}
+		`})
+		require.NoError(t, err)
+		var expectedBuffer bytes.Buffer
+		formatter.NewFormatter(&expectedBuffer).FormatSchema(expectedSchema)
+		assert.Equal(t, expectedBuffer.String(), schemaBuffer.String())
+	})
+}
+
+//go:embed testdata/introspect_input_field_default_values.json
+var introspectionInputFieldDefaultValuesJSON string
+
+func TestIntrospectInputFieldsDefaultValue(t *testing.T) {
+	t.Parallel()
+
+	schema, err := IntrospectAPI(&mockJSONQueryer{
+		JSONResult: introspectionInputFieldDefaultValuesJSON,
+	})
+	require.NoError(t, err)
+
+	var schemaBuffer bytes.Buffer
+	formatter.NewFormatter(&schemaBuffer).FormatSchema(schema)
+
+	expectedSchema, err := gqlparser.LoadSchema(&ast.Source{Input: `
+type Query {
+  hello(input: HelloInput): String
+}
+
+enum HelloEnum {
+  HELLO_1
+  HELLO_2
+}
+
+input HelloInput {
+  foo: String! = "foo"
+  bar: Int = 1
+  baz: Boolean = true
+  biff: Float = 1.23
+  boo: [String] = ["boo"]
+  bah: NestedInput = {humbug: "humbug"}
+  blah: HelloEnum = HELLO_1
+  none: String
+}
+
+input NestedInput {
+  humbug: String
+}
+	`})
+	require.NoError(t, err)
+	var expectedBuffer bytes.Buffer
+	formatter.NewFormatter(&expectedBuffer).FormatSchema(expectedSchema)
+	assert.Equal(t, expectedBuffer.String(), schemaBuffer.String())
+}
+
+func TestIntrospectAPI_interfaceHierarchy(t *testing.T) {
+	t.Parallel()
+	schema, err := IntrospectAPI(&mockJSONQueryer{
+		JSONResult: `{
+			"__schema": {
+				"queryType": {"name": "Query"},
+				"types": [
+					{
+						"kind": "OBJECT",
+						"name": "Query",
+						"fields": [
+							{"name": "user", "type": {"kind": "OBJECT", "name": "User"}}
+						],
+						"interfaces": []
+					},
+					{
+						"kind": "INTERFACE",
+						"name": "Node",
+						"fields": [
+							{"name": "id", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ID"}}}
+						],
+						"interfaces": [],
+						"possibleTypes": [
+							{"kind": "OBJECT", "name": "User"}
+						]
+					},
+					{
+						"kind": "INTERFACE",
+						"name": "Entity",
+						"fields": [
+							{"name": "id", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ID"}}}
+						],
+						"interfaces": [
+							{"kind": "INTERFACE", "name": "Node"}
+						],
+						"possibleTypes": [
+							{"kind": "OBJECT", "name": "User"}
+						]
+					},
+					{
+						"kind": "OBJECT",
+						"name": "User",
+						"fields": [
+							{"name": "id", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ID"}}}
+						],
+						"interfaces": [
+							{"kind": "INTERFACE", "name": "Entity"}
+						]
+					}
+				]
+			}
+		}`,
+	})
+	require.NoError(t, err)
+
+	typeNames := func(defs []*ast.Definition) []string {
+		var names []string
+		for _, def := range defs {
+			names = append(names, def.Name)
+		}
+		return names
+	}
+	assert.ElementsMatch(t, []string{"User", "Entity"}, typeNames(schema.GetPossibleTypes(schema.Types["Node"])))
+	assert.ElementsMatch(t, []string{"User"}, typeNames(schema.GetPossibleTypes(schema.Types["Entity"])))
+	assert.ElementsMatch(t, []string{"User", "Entity", "Node"}, typeNames(schema.GetImplements(schema.Types["User"])))
+	assert.ElementsMatch(t, []string{"Entity", "Node"}, typeNames(schema.GetImplements(schema.Types["Entity"])))
+
+	_, err = gqlparser.LoadQuery(schema, `
+query {
+    user {
+        ... on Node {
+            id
+        }
+    }
+}
+`)
+	assert.Nil(t, err, "Spreading fragment on an ancestor interface should be allowed")
+}
+
+func TestIntrospectAPI_invalidInterfaceHierarchy(t *testing.T) {
+	t.Parallel()
+	for _, row := range []struct {
+		Message string
+		Types   string
+		Error   string
+	}{
+		{
+			Message: "cycle",
+			Types: `
+				{"kind": "INTERFACE", "name": "A", "interfaces": [{"kind": "INTERFACE", "name": "B"}]},
+				{"kind": "INTERFACE", "name": "B", "interfaces": [{"kind": "INTERFACE", "name": "A"}]}
+			`,
+			Error: "interface A cannot implement itself",
+		},
+		{
+			Message: "non-interface",
+			Types: `
+				{"kind": "OBJECT", "name": "A", "interfaces": [{"kind": "OBJECT", "name": "B"}]},
+				{"kind": "OBJECT", "name": "B"}
+			`,
+			Error: "A cannot implement B because it is not an interface",
+		},
+	} {
+		row := row // enable parallel sub-tests
+		t.Run(row.Message, func(t *testing.T) {
+			t.Parallel()
+			_, err := IntrospectAPI(&mockJSONQueryer{
+				JSONResult: `{
+					"__schema": {
+						"queryType": {"name": "Query"},
+						"types": [` + row.Types + `]
+					}
+				}`,
+			})
+			assert.EqualError(t, err, row.Error)
+		})
+	}
+}
+
+func TestIntrospectRemoteSchemasConcurrently(t *testing.T) {
+	t.Parallel()
+	var inFlight, maxInFlight int32
+	client := &http.Client{
+		Transport: roundTripFunc(func(req *http.Request) *http.Response {
+			current := atomic.AddInt32(&inFlight, 1)
+			defer atomic.AddInt32(&inFlight, -1)
+			for {
+				max := atomic.LoadInt32(&maxInFlight)
+				if current <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, current) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+
+			w := httptest.NewRecorder()
+			if strings.Contains(req.URL.Host, "broken") {
+				w.WriteHeader(http.StatusInternalServerError)
+				return w.Result()
+			}
+			fmt.Fprint(w, `{"data": {"__schema": {"queryType": {"name": "Query"}}}}`)
+			return w.Result()
+		}),
+	}
+
+	urls := []string{
+		"http://service-1",
+		"http://broken-1",
+		"http://service-2",
+		"http://service-3",
+		"http://broken-2",
+	}
+	schemas, err := IntrospectRemoteSchemasConcurrently(urls, 2, IntrospectWithHTTPClient(client))
+
+	var urlsFound []string
+	for _, schema := range schemas {
+		urlsFound = append(urlsFound, schema.URL)
+		assert.NotNil(t, schema.Schema)
+	}
+	assert.Equal(t, []string{"http://service-1", "http://service-2", "http://service-3"}, urlsFound)
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxInFlight), int32(2))
+
+	var errList ErrorList
+	require.ErrorAs(t, err, &errList)
+	require.Len(t, errList, 2)
+	var remoteErr *RemoteSchemaError
+	require.ErrorAs(t, errList[0], &remoteErr)
+	assert.Equal(t, "http://broken-1", remoteErr.URL)
+	require.ErrorAs(t, errList[1], &remoteErr)
+	assert.Equal(t, "http://broken-2", remoteErr.URL)
+	assert.Contains(t, err.Error(), "could not introspect http://broken-2: query failed: response was not successful with status code: 500")
+}
+
+func TestIntrospectRemoteSchemasConcurrently_noErrors(t *testing.T) {
+	t.Parallel()
+	client := &http.Client{
+		Transport: roundTripFunc(func(req *http.Request) *http.Response {
+			w := httptest.NewRecorder()
+			fmt.Fprint(w, `{"data": {"__schema": {"queryType": {"name": "Query"}}}}`)
+			return w.Result()
+		}),
+	}
+
+	schemas, err := IntrospectRemoteSchemasConcurrently([]string{"http://service-1", "http://service-2"}, 0, IntrospectWithHTTPClient(client))
+	assert.NoError(t, err)
+	assert.Len(t, schemas, 2)
+}
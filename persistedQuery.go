@@ -0,0 +1,118 @@
+package graphql
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+)
+
+// the errors a server answers with when it doesn't know the hash of a query, or doesn't support them at all
+const (
+	persistedQueryNotFound     = "PersistedQueryNotFound"
+	persistedQueryNotSupported = "PersistedQueryNotSupported"
+)
+
+// persistedQueryCacheSize is the number of query hashes a queryer keeps around
+const persistedQueryCacheSize = 1000
+
+// persistedQueries keeps track of the hashes of the queries sent with automatic persisted queries,
+// as described in https://github.com/apollographql/apollo-link-persisted-queries#apollo-engine
+// The hashes of the most recently sent queries are cached by query text, so the queries a gateway builds on the fly
+// don't pile up.
+type persistedQueries struct {
+	mu sync.Mutex
+
+	// the cached hashes, from the most to the least recently used, and the element of each query in that list
+	recent  *list.List
+	hashes  map[string]*list.Element
+	maxSize int
+
+	// set once the server tells us it doesn't support persisted queries
+	unsupported bool
+}
+
+// persistedQueryEntry is an entry of the cache of hashes
+type persistedQueryEntry struct {
+	query string
+	hash  string
+}
+
+func newPersistedQueries() *persistedQueries {
+	return &persistedQueries{
+		recent:  list.New(),
+		hashes:  map[string]*list.Element{},
+		maxSize: persistedQueryCacheSize,
+	}
+}
+
+// extensions returns the extensions to send along with the query instead of its text. It returns false
+// if the server doesn't support persisted queries.
+func (p *persistedQueries) extensions(query string) (map[string]interface{}, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.unsupported {
+		return nil, false
+	}
+
+	return map[string]interface{}{
+		"persistedQuery": map[string]interface{}{
+			"version":    1,
+			"sha256Hash": p.hash(query),
+		},
+	}, true
+}
+
+// hash returns the hash of the query, which is only computed if it isn't cached yet. It must be called with mu held.
+func (p *persistedQueries) hash(query string) string {
+	if element, ok := p.hashes[query]; ok {
+		p.recent.MoveToFront(element)
+		return element.Value.(*persistedQueryEntry).hash
+	}
+
+	sum := sha256.Sum256([]byte(query))
+	entry := &persistedQueryEntry{query: query, hash: hex.EncodeToString(sum[:])}
+	p.hashes[query] = p.recent.PushFront(entry)
+
+	// make room by dropping the least recently used hash
+	if p.recent.Len() > p.maxSize {
+		oldest := p.recent.Back()
+		p.recent.Remove(oldest)
+		delete(p.hashes, oldest.Value.(*persistedQueryEntry).query)
+	}
+	return entry.hash
+}
+
+// missing returns true if the result of a request that only had the hash of the query says that the
+// query needs to be sent again with its text
+func (p *persistedQueries) missing(result map[string]interface{}) bool {
+	errs, ok := result["errors"].([]interface{})
+	if !ok {
+		return false
+	}
+
+	for _, err := range errs {
+		obj, ok := err.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		message, _ := obj["message"].(string)
+		code := ""
+		if extensions, ok := obj["extensions"].(map[string]interface{}); ok {
+			code, _ = extensions["code"].(string)
+		}
+
+		switch {
+		case message == persistedQueryNotFound || code == "PERSISTED_QUERY_NOT_FOUND":
+			return true
+		case message == persistedQueryNotSupported || code == "PERSISTED_QUERY_NOT_SUPPORTED":
+			// there's no point in sending hashes anymore
+			p.mu.Lock()
+			p.unsupported = true
+			p.mu.Unlock()
+			return true
+		}
+	}
+
+	return false
+}
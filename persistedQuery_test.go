@@ -0,0 +1,267 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// persistedQueryTransport answers requests like a server with automatic persisted queries. It remembers the
+// queries it has seen by hash and records every payload it receives.
+type persistedQueryTransport struct {
+	mu          sync.Mutex
+	known       map[string]string
+	unsupported bool
+	methods     []string
+	payloads    []map[string]interface{}
+}
+
+func newPersistedQueryTransport() *persistedQueryTransport {
+	return &persistedQueryTransport{known: map[string]string{}}
+}
+
+func (s *persistedQueryTransport) client() *http.Client {
+	return &http.Client{
+		Transport: roundTripFunc(func(req *http.Request) *http.Response {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.methods = append(s.methods, req.Method)
+
+			var body []byte
+			if req.Method == http.MethodGet {
+				// GET requests carry the payload in their query string
+				payload := map[string]interface{}{}
+				for key, values := range req.URL.Query() {
+					var value interface{} = values[0]
+					if key == "extensions" || key == "variables" {
+						var decoded map[string]interface{}
+						_ = json.Unmarshal([]byte(values[0]), &decoded)
+						value = decoded
+					}
+					payload[key] = value
+				}
+				body, _ = json.Marshal(s.answer(payload))
+			} else {
+				var raw json.RawMessage
+				_ = json.NewDecoder(req.Body).Decode(&raw)
+
+				// batches are answered entry by entry
+				batch := []map[string]interface{}{}
+				if json.Unmarshal(raw, &batch) == nil {
+					results := []map[string]interface{}{}
+					for _, payload := range batch {
+						results = append(results, s.answer(payload))
+					}
+					body, _ = json.Marshal(results)
+				} else {
+					payload := map[string]interface{}{}
+					_ = json.Unmarshal(raw, &payload)
+					body, _ = json.Marshal(s.answer(payload))
+				}
+			}
+
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBuffer(body)),
+				Header:     make(http.Header),
+			}
+		}),
+	}
+}
+
+func (s *persistedQueryTransport) answer(payload map[string]interface{}) map[string]interface{} {
+	s.payloads = append(s.payloads, payload)
+
+	query, _ := payload["query"].(string)
+	hash := ""
+	if extensions, ok := payload["extensions"].(map[string]interface{}); ok {
+		persisted, _ := extensions["persistedQuery"].(map[string]interface{})
+		hash, _ = persisted["sha256Hash"].(string)
+	}
+
+	switch {
+	case hash != "" && s.unsupported:
+		return map[string]interface{}{"errors": []interface{}{map[string]interface{}{"message": "PersistedQueryNotSupported"}}}
+	case hash != "" && query != "":
+		s.known[hash] = query
+	case hash != "":
+		if query = s.known[hash]; query == "" {
+			return map[string]interface{}{"errors": []interface{}{
+				map[string]interface{}{"message": "PersistedQueryNotFound", "extensions": map[string]interface{}{"code": "PERSISTED_QUERY_NOT_FOUND"}},
+			}}
+		}
+	}
+
+	return map[string]interface{}{"data": map[string]interface{}{"query": query}}
+}
+
+func (s *persistedQueryTransport) requests() ([]string, []map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	methods, payloads := s.methods, s.payloads
+	s.methods, s.payloads = nil, nil
+	return methods, payloads
+}
+
+func persistedQueryHash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+func persistedQueryExtensions(query string) map[string]interface{} {
+	return map[string]interface{}{
+		"persistedQuery": map[string]interface{}{"version": float64(1), "sha256Hash": persistedQueryHash(query)},
+	}
+}
+
+func TestSingleRequestQueryer_persistedQueries(t *testing.T) {
+	t.Parallel()
+	server := newPersistedQueryTransport()
+	queryer := NewSingleRequestQueryer("http://localhost/graphql").
+		WithHTTPClient(server.client()).(*SingleRequestQueryer).
+		WithAutomaticPersistedQueries()
+
+	query := "query me { me { name } }"
+	input := &QueryInput{Query: query, OperationName: "me"}
+
+	// the first time around the server asks for the query
+	result := map[string]interface{}{}
+	require.NoError(t, queryer.Query(context.Background(), input, &result))
+	assert.Equal(t, map[string]interface{}{"query": query}, result)
+
+	_, payloads := server.requests()
+	assert.Equal(t, []map[string]interface{}{
+		{"operationName": "me", "variables": nil, "extensions": persistedQueryExtensions(query)},
+		{"operationName": "me", "variables": nil, "extensions": persistedQueryExtensions(query), "query": query},
+	}, payloads)
+
+	// and then only needs the hash
+	result = map[string]interface{}{}
+	require.NoError(t, queryer.Query(context.Background(), input, &result))
+	assert.Equal(t, map[string]interface{}{"query": query}, result)
+
+	_, payloads = server.requests()
+	assert.Equal(t, []map[string]interface{}{
+		{"operationName": "me", "variables": nil, "extensions": persistedQueryExtensions(query)},
+	}, payloads)
+}
+
+func TestSingleRequestQueryer_persistedQueriesWithGet(t *testing.T) {
+	t.Parallel()
+	server := newPersistedQueryTransport()
+	queryer := NewSingleRequestQueryer("http://localhost/graphql").
+		WithHTTPClient(server.client()).(*SingleRequestQueryer).
+		WithGetQueries(0).(*SingleRequestQueryer).
+		WithAutomaticPersistedQueries()
+
+	query := "{ me { name } }"
+	for i := 0; i < 2; i++ {
+		result := map[string]interface{}{}
+		require.NoError(t, queryer.Query(context.Background(), &QueryInput{Query: query}, &result))
+		assert.Equal(t, map[string]interface{}{"query": query}, result)
+	}
+
+	// the hash is sent in the query string, which caches can store
+	methods, payloads := server.requests()
+	assert.Equal(t, []string{http.MethodGet, http.MethodGet, http.MethodGet}, methods)
+	assert.Equal(t, []map[string]interface{}{
+		{"extensions": persistedQueryExtensions(query)},
+		{"extensions": persistedQueryExtensions(query), "query": query},
+		{"extensions": persistedQueryExtensions(query)},
+	}, payloads)
+
+	// mutations are never sent with GET
+	require.NoError(t, queryer.Query(context.Background(), &QueryInput{Query: "mutation { logout }"}, &map[string]interface{}{}))
+	methods, _ = server.requests()
+	assert.Equal(t, []string{http.MethodPost, http.MethodPost}, methods)
+}
+
+func TestSingleRequestQueryer_persistedQueriesNotSupported(t *testing.T) {
+	t.Parallel()
+	server := newPersistedQueryTransport()
+	server.unsupported = true
+	queryer := NewSingleRequestQueryer("http://localhost/graphql").
+		WithHTTPClient(server.client()).(*SingleRequestQueryer).
+		WithAutomaticPersistedQueries()
+
+	query := "{ me { name } }"
+	result := map[string]interface{}{}
+	require.NoError(t, queryer.Query(context.Background(), &QueryInput{Query: query}, &result))
+	assert.Equal(t, map[string]interface{}{"query": query}, result)
+	_, payloads := server.requests()
+	assert.Len(t, payloads, 2)
+
+	// the queryer stops sending hashes
+	require.NoError(t, queryer.Query(context.Background(), &QueryInput{Query: query}, &result))
+	_, payloads = server.requests()
+	assert.Equal(t, []map[string]interface{}{
+		{"operationName": "", "variables": nil, "query": query},
+	}, payloads)
+}
+
+func TestMultiOpQueryer_persistedQueries(t *testing.T) {
+	t.Parallel()
+	server := newPersistedQueryTransport()
+	queryer := NewMultiOpQueryer("http://localhost/graphql", 10*time.Millisecond, 10).
+		WithHTTPClient(server.client()).(*MultiOpQueryer).
+		WithAutomaticPersistedQueries()
+
+	known := "{ known }"
+	unknown := "{ unknown }"
+	server.known[persistedQueryHash(known)] = known
+
+	results := make([]map[string]interface{}, 2)
+	var wg sync.WaitGroup
+	for i, query := range []string{known, unknown} {
+		wg.Add(1)
+		go func(i int, query string) {
+			defer wg.Done()
+			results[i] = map[string]interface{}{}
+			assert.NoError(t, queryer.Query(context.Background(), &QueryInput{Query: query}, &results[i]))
+		}(i, query)
+	}
+	wg.Wait()
+	assert.Equal(t, []map[string]interface{}{{"query": known}, {"query": unknown}}, results)
+
+	// both hashes go out in one batch and only the unknown query is sent again
+	methods, payloads := server.requests()
+	assert.Equal(t, []string{http.MethodPost, http.MethodPost}, methods)
+	require.Len(t, payloads, 3)
+	for _, payload := range payloads[:2] {
+		assert.NotContains(t, payload, "query")
+	}
+	assert.Equal(t, map[string]interface{}{
+		"operationName": "",
+		"variables":     nil,
+		"extensions":    persistedQueryExtensions(unknown),
+		"query":         unknown,
+	}, payloads[2])
+}
+
+func TestPersistedQueries_cache(t *testing.T) {
+	t.Parallel()
+	cache := newPersistedQueries()
+	cache.maxSize = 2
+
+	for _, query := range []string{"{ a }", "{ b }", "{ a }", "{ c }"} {
+		extensions, ok := cache.extensions(query)
+		require.True(t, ok)
+		assert.Equal(t, persistedQueryHash(query), extensions["persistedQuery"].(map[string]interface{})["sha256Hash"])
+	}
+
+	// only the most recently used hashes are kept
+	assert.Equal(t, 2, cache.recent.Len())
+	assert.Contains(t, cache.hashes, "{ a }")
+	assert.Contains(t, cache.hashes, "{ c }")
+	assert.NotContains(t, cache.hashes, "{ b }")
+}
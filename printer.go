@@ -2,6 +2,8 @@ package graphql
 
 import (
 	"bytes"
+	"sort"
+	"strings"
 
 	"github.com/vektah/gqlparser/v2/ast"
 	"github.com/vektah/gqlparser/v2/formatter"
@@ -13,3 +15,220 @@ func PrintQuery(document *ast.QueryDocument) (string, error) {
 	formatter.NewFormatter(&buf).FormatQueryDocument(document)
 	return buf.String(), nil
 }
+
+// PrintSchemaOption configures the output of PrintSchema
+type PrintSchemaOption func(opts *printSchemaOptions)
+
+type printSchemaOptions struct {
+	builtins       bool
+	sorted         bool
+	noDescriptions bool
+}
+
+// PrintSchemaWithBuiltins includes the built-in scalars, introspection types and directives in the output
+func PrintSchemaWithBuiltins() PrintSchemaOption {
+	return func(opts *printSchemaOptions) {
+		opts.builtins = true
+	}
+}
+
+// PrintSchemaSorted prints directives, types, fields and enum values in alphabetical order
+// instead of the order they were defined in
+func PrintSchemaSorted() PrintSchemaOption {
+	return func(opts *printSchemaOptions) {
+		opts.sorted = true
+	}
+}
+
+// PrintSchemaWithoutDescriptions leaves the descriptions out of the output
+func PrintSchemaWithoutDescriptions() PrintSchemaOption {
+	return func(opts *printSchemaOptions) {
+		opts.noDescriptions = true
+	}
+}
+
+// PrintSchema creates the SDL representation of a schema. The schema definition is only printed when it
+// carries information, like root types that don't use the default names. Definitions are printed in the
+// order they were defined in, with definitions that have no position (e.g. introspected ones) sorted by name.
+func PrintSchema(schema *ast.Schema, opts ...PrintSchemaOption) (string, error) {
+	options := &printSchemaOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	// each definition is formatted on its own so they can be separated by a blank line
+	blocks := []*ast.SchemaDocument{}
+	if definition := printSchemaDefinition(schema, options); definition != nil {
+		blocks = append(blocks, &ast.SchemaDocument{Schema: ast.SchemaDefinitionList{definition}})
+	}
+
+	directives := []*ast.DirectiveDefinition{}
+	for _, directive := range schema.Directives {
+		if options.builtins || !isBuiltInDirective(directive) {
+			directives = append(directives, printDirectiveDefinition(directive, options))
+		}
+	}
+	sort.SliceStable(directives, func(i, j int) bool {
+		return printSchemaLess(options, directives[i].Name, directives[j].Name, directives[i].Position, directives[j].Position)
+	})
+	for _, directive := range directives {
+		blocks = append(blocks, &ast.SchemaDocument{Directives: ast.DirectiveDefinitionList{directive}})
+	}
+
+	definitions := []*ast.Definition{}
+	for _, definition := range schema.Types {
+		if options.builtins || !definition.BuiltIn {
+			definitions = append(definitions, printDefinition(definition, options))
+		}
+	}
+	sort.SliceStable(definitions, func(i, j int) bool {
+		return printSchemaLess(options, definitions[i].Name, definitions[j].Name, definitions[i].Position, definitions[j].Position)
+	})
+	for _, definition := range definitions {
+		blocks = append(blocks, &ast.SchemaDocument{Definitions: ast.DefinitionList{definition}})
+	}
+
+	var buf bytes.Buffer
+	for i, block := range blocks {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		// built-ins have already been filtered out and definitions without a source would trip up the formatter
+		formatter.NewFormatter(&buf, formatter.WithBuiltin()).FormatSchemaDocument(block)
+	}
+	return buf.String(), nil
+}
+
+// printSchemaDefinition returns the schema definition to print, or nil if the defaults describe the schema
+func printSchemaDefinition(schema *ast.Schema, options *printSchemaOptions) *ast.SchemaDefinition {
+	definition := &ast.SchemaDefinition{}
+	if !options.noDescriptions {
+		definition.Description = schema.Description
+	}
+
+	needed := definition.Description != ""
+	for _, root := range []struct {
+		operation   ast.Operation
+		definition  *ast.Definition
+		defaultName string
+	}{
+		{ast.Query, schema.Query, "Query"},
+		{ast.Mutation, schema.Mutation, "Mutation"},
+		{ast.Subscription, schema.Subscription, "Subscription"},
+	} {
+		if root.definition == nil {
+			// a type with the default name would become the root type when parsed again
+			needed = needed || schema.Types[root.defaultName] != nil
+			continue
+		}
+		needed = needed || root.definition.Name != root.defaultName
+		definition.OperationTypes = append(definition.OperationTypes, &ast.OperationTypeDefinition{
+			Operation: root.operation,
+			Type:      root.definition.Name,
+		})
+	}
+
+	if !needed {
+		return nil
+	}
+	return definition
+}
+
+// printDefinition returns a copy of the definition with the options applied
+func printDefinition(definition *ast.Definition, options *printSchemaOptions) *ast.Definition {
+	printed := *definition
+
+	printed.Fields = ast.FieldList{}
+	for _, field := range definition.Fields {
+		if !options.builtins && strings.HasPrefix(field.Name, "__") {
+			continue
+		}
+		printedField := *field
+		printedField.Arguments = printArgumentDefinitions(field.Arguments, options)
+		if options.noDescriptions {
+			printedField.Description = ""
+		}
+		printed.Fields = append(printed.Fields, &printedField)
+	}
+
+	printed.EnumValues = ast.EnumValueList{}
+	for _, value := range definition.EnumValues {
+		printedValue := *value
+		if options.noDescriptions {
+			printedValue.Description = ""
+		}
+		printed.EnumValues = append(printed.EnumValues, &printedValue)
+	}
+
+	printed.Interfaces = append([]string{}, definition.Interfaces...)
+	printed.Types = append([]string{}, definition.Types...)
+
+	if options.noDescriptions {
+		printed.Description = ""
+	}
+	if options.sorted {
+		sort.SliceStable(printed.Fields, func(i, j int) bool {
+			return printed.Fields[i].Name < printed.Fields[j].Name
+		})
+		sort.SliceStable(printed.EnumValues, func(i, j int) bool {
+			return printed.EnumValues[i].Name < printed.EnumValues[j].Name
+		})
+		sort.Strings(printed.Interfaces)
+		sort.Strings(printed.Types)
+	}
+
+	return &printed
+}
+
+// printDirectiveDefinition returns a copy of the directive definition with the options applied
+func printDirectiveDefinition(directive *ast.DirectiveDefinition, options *printSchemaOptions) *ast.DirectiveDefinition {
+	printed := *directive
+	printed.Arguments = printArgumentDefinitions(directive.Arguments, options)
+	if options.noDescriptions {
+		printed.Description = ""
+	}
+	return &printed
+}
+
+func printArgumentDefinitions(args ast.ArgumentDefinitionList, options *printSchemaOptions) ast.ArgumentDefinitionList {
+	if !options.noDescriptions {
+		return args
+	}
+
+	printed := ast.ArgumentDefinitionList{}
+	for _, arg := range args {
+		printedArg := *arg
+		printedArg.Description = ""
+		printed = append(printed, &printedArg)
+	}
+	return printed
+}
+
+// printSchemaLess orders definitions by name when sorting, or else by where they were defined
+func printSchemaLess(options *printSchemaOptions, nameA, nameB string, positionA, positionB *ast.Position) bool {
+	if !options.sorted {
+		srcA, lineA, columnA := printSchemaPosition(positionA)
+		srcB, lineB, columnB := printSchemaPosition(positionB)
+		if srcA != srcB {
+			return srcA < srcB
+		}
+		if lineA != lineB {
+			return lineA < lineB
+		}
+		if columnA != columnB {
+			return columnA < columnB
+		}
+	}
+	return nameA < nameB
+}
+
+func printSchemaPosition(position *ast.Position) (string, int, int) {
+	if position == nil {
+		return "", 0, 0
+	}
+	src := ""
+	if position.Src != nil {
+		src = position.Src.Name
+	}
+	return src, position.Line, position.Column
+}
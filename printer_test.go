@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/vektah/gqlparser/v2/ast"
 )
 
@@ -739,3 +740,209 @@ fragment Foo on User {
 		})
 	}
 }
+
+func TestPrintSchema(t *testing.T) {
+	t.Parallel()
+	source := `
+		"""
+		the things
+		"""
+		type Query {
+			"""
+			find things
+			"""
+			things(first: Int = 10): [Thing!]! @deprecated(reason: "use search")
+			search(query: String!): SearchResult
+		}
+
+		interface Node {
+			id: ID!
+		}
+
+		type Thing implements Node {
+			id: ID!
+			kind: Kind
+		}
+
+		enum Kind {
+			SMALL
+			LARGE @deprecated
+		}
+
+		union SearchResult = Thing
+
+		directive @cached(ttl: Int) repeatable on FIELD_DEFINITION | OBJECT
+	`
+
+	for _, row := range []struct {
+		Message  string
+		Options  []PrintSchemaOption
+		Expected string
+	}{
+		{
+			Message: "source order",
+			Expected: `directive @cached(ttl: Int) repeatable on FIELD_DEFINITION | OBJECT
+
+"""
+the things
+"""
+type Query {
+	"""
+	find things
+	"""
+	things(first: Int = 10): [Thing!]! @deprecated(reason: "use search")
+	search(query: String!): SearchResult
+}
+
+interface Node {
+	id: ID!
+}
+
+type Thing implements Node {
+	id: ID!
+	kind: Kind
+}
+
+enum Kind {
+	SMALL
+	LARGE @deprecated
+}
+
+union SearchResult = Thing
+`,
+		},
+		{
+			Message: "sorted without descriptions",
+			Options: []PrintSchemaOption{PrintSchemaSorted(), PrintSchemaWithoutDescriptions()},
+			Expected: `directive @cached(ttl: Int) repeatable on FIELD_DEFINITION | OBJECT
+
+enum Kind {
+	LARGE @deprecated
+	SMALL
+}
+
+interface Node {
+	id: ID!
+}
+
+type Query {
+	search(query: String!): SearchResult
+	things(first: Int = 10): [Thing!]! @deprecated(reason: "use search")
+}
+
+union SearchResult = Thing
+
+type Thing implements Node {
+	id: ID!
+	kind: Kind
+}
+`,
+		},
+	} {
+		row := row
+		t.Run(row.Message, func(t *testing.T) {
+			t.Parallel()
+			schema, err := LoadSchema(source)
+			require.NoError(t, err)
+
+			printed, err := PrintSchema(schema, row.Options...)
+			require.NoError(t, err)
+			assert.Equal(t, row.Expected, printed)
+
+			// the output should describe the same schema
+			reparsed, err := LoadSchema(printed)
+			require.NoError(t, err)
+			reprinted, err := PrintSchema(reparsed, row.Options...)
+			require.NoError(t, err)
+			assert.Equal(t, printed, reprinted)
+		})
+	}
+}
+
+func TestPrintSchema_schemaDefinition(t *testing.T) {
+	t.Parallel()
+	for _, row := range []struct {
+		Message  string
+		Source   string
+		Expected string
+	}{
+		{
+			Message:  "default root names",
+			Source:   `type Query { a: Int } type Mutation { a: Int }`,
+			Expected: "",
+		},
+		{
+			Message: "custom root names",
+			Source:  `schema { query: RootQuery, mutation: Mutation } type RootQuery { a: Int } type Mutation { a: Int }`,
+			Expected: `schema {
+	query: RootQuery
+	mutation: Mutation
+}
+`,
+		},
+		{
+			Message: "type with a root name that is not a root",
+			Source:  `schema { query: Query } type Query { a: Int } type Mutation { a: Int }`,
+			Expected: `schema {
+	query: Query
+}
+`,
+		},
+	} {
+		row := row
+		t.Run(row.Message, func(t *testing.T) {
+			t.Parallel()
+			schema, err := LoadSchema(row.Source)
+			require.NoError(t, err)
+
+			printed, err := PrintSchema(schema)
+			require.NoError(t, err)
+			if row.Expected == "" {
+				assert.NotContains(t, printed, "schema {")
+			} else {
+				assert.Contains(t, printed, row.Expected)
+			}
+
+			reparsed, err := LoadSchema(printed)
+			require.NoError(t, err)
+			assert.Equal(t, schema.Query.Name, reparsed.Query.Name)
+			assert.Equal(t, schema.Mutation == nil, reparsed.Mutation == nil)
+		})
+	}
+}
+
+func TestPrintSchema_builtins(t *testing.T) {
+	t.Parallel()
+	schema, err := LoadSchema(`type Query { a: Int }`)
+	require.NoError(t, err)
+
+	printed, err := PrintSchema(schema)
+	require.NoError(t, err)
+	assert.NotContains(t, printed, "scalar Int")
+	assert.NotContains(t, printed, "__schema")
+
+	printed, err = PrintSchema(schema, PrintSchemaWithBuiltins())
+	require.NoError(t, err)
+	assert.Contains(t, printed, "scalar Int")
+	assert.Contains(t, printed, "directive @skip")
+	assert.Contains(t, printed, "type __Schema")
+}
+
+func TestPrintSchema_introspected(t *testing.T) {
+	t.Parallel()
+	schema, err := LoadSchema(`type Query { a(x: Int = 1): String } scalar Date directive @cached on FIELD_DEFINITION`)
+	require.NoError(t, err)
+	introspected, err := BuildSchemaFromIntrospection(NewIntrospectionQueryResult(schema))
+	require.NoError(t, err)
+
+	printed, err := PrintSchema(introspected)
+	require.NoError(t, err)
+	assert.Equal(t, `directive @cached on FIELD_DEFINITION
+
+scalar Date
+
+type Query {
+	a(x: Int = 1): String
+}
+`, printed)
+}
@@ -5,8 +5,10 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"reflect"
 	"strconv"
 
@@ -59,6 +61,12 @@ type QueryerWithMiddlewares interface {
 	WithMiddlewares(wares []NetworkMiddleware) Queryer
 }
 
+// QueryerWithScalars is an interface for queryers that can serialize variables and parse responses
+// with the custom scalars of a schema
+type QueryerWithScalars interface {
+	WithScalars(schema *ast.Schema, scalars *ScalarRegistry) Queryer
+}
+
 // HTTPQueryer is an interface for queryers that let you configure an underlying http.Client
 type HTTPQueryer interface {
 	WithHTTPClient(client *http.Client) Queryer
@@ -81,10 +89,7 @@ type MockSuccessQueryer struct {
 // Query looks up the name of the query in the map of responses and returns the value
 func (q *MockSuccessQueryer) Query(ctx context.Context, input *QueryInput, receiver interface{}) error {
 	// assume the mock is writing the same kind as the receiver
-	reflect.ValueOf(receiver).Elem().Set(reflect.ValueOf(q.Value))
-
-	// this will panic if something goes wrong
-	return nil
+	return setMockResponse(receiver, q.Value)
 }
 
 // QueryerFunc responds to the query by calling the provided function
@@ -96,11 +101,25 @@ func (q QueryerFunc) Query(ctx context.Context, input *QueryInput, receiver inte
 	response, responseErr := q(input)
 	if response != nil {
 		// assume the mock is writing the same kind as the receiver
-		reflect.ValueOf(receiver).Elem().Set(reflect.ValueOf(response))
+		if err := setMockResponse(receiver, response); err != nil {
+			return err
+		}
 	}
 	return responseErr // support partial success: always return the queryer error after setting the return data
 }
 
+// setMockResponse writes the response of a mock to the receiver. Responses of another type, like the standard
+// introspection result for the query IntrospectAPI sends to detect features, are an error instead of a panic.
+func setMockResponse(receiver interface{}, response interface{}) error {
+	target := reflect.ValueOf(receiver).Elem()
+	value := reflect.ValueOf(response)
+	if !value.Type().AssignableTo(target.Type()) {
+		return fmt.Errorf("mock response of type %s cannot be written to %s", value.Type(), target.Type())
+	}
+	target.Set(value)
+	return nil
+}
+
 type NetworkQueryer struct {
 	URL         string
 	Middlewares []NetworkMiddleware
@@ -109,10 +128,15 @@ type NetworkQueryer struct {
 
 // SendQuery is responsible for sending the provided payload to the desingated URL
 func (q *NetworkQueryer) SendQuery(ctx context.Context, payload []byte) ([]byte, error) {
+	body, _, err := q.sendQuery(ctx, payload)
+	return body, err
+}
+
+func (q *NetworkQueryer) sendQuery(ctx context.Context, payload []byte) ([]byte, *http.Response, error) {
 	// construct the initial request we will send to the client
 	req, err := http.NewRequest("POST", q.URL, bytes.NewBuffer(payload))
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	// add the current context to the request
 	acc := req.WithContext(ctx)
@@ -123,10 +147,15 @@ func (q *NetworkQueryer) SendQuery(ctx context.Context, payload []byte) ([]byte,
 
 // SendMultipart is responsible for sending multipart request to the desingated URL
 func (q *NetworkQueryer) SendMultipart(ctx context.Context, payload []byte, contentType string) ([]byte, error) {
+	body, _, err := q.sendMultipart(ctx, payload, contentType)
+	return body, err
+}
+
+func (q *NetworkQueryer) sendMultipart(ctx context.Context, payload []byte, contentType string) ([]byte, *http.Response, error) {
 	// construct the initial request we will send to the client
 	req, err := http.NewRequest("POST", q.URL, bytes.NewBuffer(payload))
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	// add the current context to the request
 	acc := req.WithContext(ctx)
@@ -135,39 +164,116 @@ func (q *NetworkQueryer) SendMultipart(ctx context.Context, payload []byte, cont
 	return q.sendRequest(acc)
 }
 
-func (q *NetworkQueryer) sendRequest(acc *http.Request) ([]byte, error) {
-	// we could have any number of middlewares that we have to go through so
-	for _, mware := range q.Middlewares {
-		err := mware(acc)
-		if err != nil {
-			return nil, err
-		}
+// SendGet is responsible for sending a GET request to the designated URL with the provided parameters
+// added to its query string
+func (q *NetworkQueryer) SendGet(ctx context.Context, params url.Values) ([]byte, error) {
+	body, _, err := q.sendGet(ctx, params)
+	return body, err
+}
+
+func (q *NetworkQueryer) sendGet(ctx context.Context, params url.Values) ([]byte, *http.Response, error) {
+	target, err := q.getURL(params)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// fire the response to the queryer's url
-	if q.Client == nil {
-		q.Client = &http.Client{}
+	// construct the initial request we will send to the client
+	req, err := http.NewRequest("GET", target, nil)
+	if err != nil {
+		return nil, nil, err
 	}
+	// add the current context to the request
+	acc := req.WithContext(ctx)
+	acc.Header.Set("Accept", "application/json")
 
-	resp, err := q.Client.Do(acc)
+	return q.sendRequest(acc)
+}
+
+// getURL returns the URL of the queryer with the provided parameters added to its query string
+func (q *NetworkQueryer) getURL(params url.Values) (string, error) {
+	target, err := url.Parse(q.URL)
+	if err != nil {
+		return "", err
+	}
+
+	// keep whatever was already in the query string
+	query := target.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	target.RawQuery = query.Encode()
+
+	return target.String(), nil
+}
+
+// SendEventStream sends the provided payload to the designated URL asking for a text/event-stream response,
+// which is returned as soon as the server starts to send it. A non-empty lastEventID is sent in the Last-Event-ID
+// header to resume a stream that was interrupted. The caller must close the body of the response.
+func (q *NetworkQueryer) SendEventStream(ctx context.Context, payload []byte, lastEventID string) (*http.Response, error) {
+	req, err := http.NewRequest("POST", q.URL, bytes.NewBuffer(payload))
 	if err != nil {
 		return nil, err
 	}
+	acc := req.WithContext(ctx)
+	acc.Header.Set("Content-Type", "application/json")
+	acc.Header.Set("Accept", "text/event-stream, application/json")
+	if lastEventID != "" {
+		acc.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	resp, err := q.do(acc)
+	if err != nil {
+		return nil, err
+	}
+
+	// check for HTTP errors
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, errors.New("response was not successful with status code: " + strconv.Itoa(resp.StatusCode))
+	}
+	return resp, nil
+}
+
+// sendRequest sends the request and reads the body of the response, which is returned along with the
+// response for its status and headers
+func (q *NetworkQueryer) sendRequest(acc *http.Request) ([]byte, *http.Response, error) {
+	resp, err := q.do(acc)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	// read the full body
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, resp, err
 	}
 	defer resp.Body.Close()
 
 	// check for HTTP errors
 	if resp.StatusCode < 200 || resp.StatusCode > 299 {
-		return body, errors.New("response was not successful with status code: " + strconv.Itoa(resp.StatusCode))
+		return body, resp, errors.New("response was not successful with status code: " + strconv.Itoa(resp.StatusCode))
 	}
 
 	// we're done
-	return body, err
+	return body, resp, err
+}
+
+// do applies the middlewares to the request and sends it
+func (q *NetworkQueryer) do(acc *http.Request) (*http.Response, error) {
+	// we could have any number of middlewares that we have to go through so
+	for _, mware := range q.Middlewares {
+		err := mware(acc)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// fire the response to the queryer's url
+	if q.Client == nil {
+		q.Client = &http.Client{}
+	}
+
+	return q.Client.Do(acc)
 }
 
 // ExtractErrors takes the result from a remote query and writes it to the provided pointer
@@ -7,8 +7,8 @@ import (
 	"net/http"
 	"time"
 
-	"github.com/go-viper/mapstructure/v2"
 	"github.com/graph-gophers/dataloader"
+	"github.com/vektah/gqlparser/v2/ast"
 )
 
 // MultiOpQueryer is a queryer that will batch subsequent query on some interval into a single network request
@@ -20,6 +20,13 @@ type MultiOpQueryer struct {
 	// internals for bundling queries
 	queryer *NetworkQueryer
 	loader  *dataloader.Loader
+
+	// the custom scalars of the schema, if any
+	schema  *ast.Schema
+	scalars *ScalarRegistry
+
+	// the cache of query hashes, if the queries are sent as automatic persisted queries
+	persistedQueries *persistedQueries
 }
 
 // NewMultiOpQueryer returns a MultiOpQueryer with the provided parameters
@@ -58,76 +65,193 @@ func (q *MultiOpQueryer) WithHTTPClient(client *http.Client) Queryer {
 	return q
 }
 
+// WithScalars lets the user serialize variables and parse the responses with the custom scalars of the schema
+func (q *MultiOpQueryer) WithScalars(schema *ast.Schema, scalars *ScalarRegistry) Queryer {
+	q.schema = schema
+	q.scalars = scalars
+	return q
+}
+
+// WithAutomaticPersistedQueries makes the queryer send the hash of each query instead of the query itself, as long as
+// the server has seen it before. Unknown queries are sent again with their text, in a second batch.
+// Their hashes are cached by query text, for the most recently sent queries.
+func (q *MultiOpQueryer) WithAutomaticPersistedQueries() Queryer {
+	q.persistedQueries = newPersistedQueries()
+	return q
+}
+
 // Query bundles queries that happen within the given interval into a single network request
 // whose body is a list of the operation payload.
 func (q *MultiOpQueryer) Query(ctx context.Context, input *QueryInput, receiver interface{}) error {
+	// custom scalars are serialized before the input joins a batch
+	if q.scalars != nil {
+		serialized, err := q.scalars.SerializeVariables(q.schema, input)
+		if err != nil {
+			return err
+		}
+		input = serialized
+	}
+
 	// process the input
 	result, err := q.loader.Load(ctx, input)()
 	if err != nil {
+		// the response of a failed batch can still be useful, to respect a rate limit for example
+		if batched, ok := result.(*multiOpResult); ok {
+			return decodeFailedResponse(q.queryer, batched.data, batched.response, receiver, err)
+		}
 		return err
 	}
 
-	unmarshaled, ok := result.(map[string]interface{})
-	if !ok {
+	batched, ok := result.(*multiOpResult)
+	if !ok || batched.data == nil {
 		return errors.New("Result from dataloader was not an object")
 	}
+	if q.scalars != nil {
+		if err := parseResponseScalars(q.scalars, q.schema, input, batched.data); err != nil {
+			return err
+		}
+	}
 
 	// format the result as needed
-	// assign the result under the data key to the receiver
-	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
-		TagName: "json",
-		Result:  receiver,
-	})
-	if err != nil {
-		return err
-	}
-	if err := decoder.Decode(unmarshaled["data"]); err != nil {
-		return err
-	}
+	return decodeResponse(q.queryer, batched.data, batched.response, receiver)
+}
 
-	return q.queryer.ExtractErrors(unmarshaled)
+// multiOpResult is the result of an operation along with the HTTP response of the batch it was sent in
+type multiOpResult struct {
+	data     map[string]interface{}
+	response *http.Response
 }
 
 func (q *MultiOpQueryer) loadQuery(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
 	// a place to store the results
 	results := []*dataloader.Result{}
 
-	// the keys serialize to the correct representation
-	payload, err := json.Marshal(keys)
-	if err != nil {
-		// we need to result the same error for each result
-		for range keys {
-			results = append(results, &dataloader.Result{Error: err})
+	// the keys serialize to the correct representation, unless we only send the hash of their query
+	requests := make([]interface{}, len(keys))
+	hashed := make([]bool, len(keys))
+	for i, key := range keys {
+		requests[i] = key
+
+		input, ok := key.(*QueryInput)
+		if !ok || q.persistedQueries == nil {
+			continue
+		}
+		if extensions, ok := q.persistedQueries.extensions(input.Query); ok {
+			hashed[i] = true
+			requests[i] = map[string]interface{}{
+				"variables":     input.Variables,
+				"operationName": input.OperationName,
+				"extensions":    extensions,
+			}
 		}
-		return results
 	}
 
-	// send the payload to the server
-	response, err := q.queryer.SendQuery(ctx, payload)
+	queryResults, resp, err := q.sendBatch(ctx, requests)
 	if err != nil {
-		// we need to result the same error for each result
-		for range keys {
-			results = append(results, &dataloader.Result{Error: err})
+		// we need to result the same error for each result, along with what the server said about it
+		for i := range keys {
+			failed := &multiOpResult{response: resp}
+			if i < len(queryResults) {
+				failed.data = queryResults[i]
+			}
+			results = append(results, &dataloader.Result{Data: failed, Error: err})
 		}
 		return results
 	}
+	batched := make([]*multiOpResult, len(queryResults))
+	for i, result := range queryResults {
+		batched[i] = &multiOpResult{data: result, response: resp}
+	}
 
-	// a place to handle each result
-	queryResults := []map[string]interface{}{}
-	err = json.Unmarshal(response, &queryResults)
-	if err != nil {
-		// we need to result the same error for each result
-		for range keys {
-			results = append(results, &dataloader.Result{Error: err})
+	// the queries the server doesn't know yet are sent again, with their text
+	missing := []int{}
+	retries := []interface{}{}
+	for i, result := range queryResults {
+		if i < len(hashed) && hashed[i] && q.persistedQueries.missing(result) {
+			input := keys[i].(*QueryInput)
+			retry := map[string]interface{}{
+				"query":         input.Query,
+				"variables":     input.Variables,
+				"operationName": input.OperationName,
+			}
+			// unless the server turned out not to support them, the hash goes along with the query
+			if extensions, ok := q.persistedQueries.extensions(input.Query); ok {
+				retry["extensions"] = extensions
+			}
+			missing = append(missing, i)
+			retries = append(retries, retry)
+		}
+	}
+	var retryErr error
+	var retryResp *http.Response
+	if len(retries) > 0 {
+		retried, resp, err := q.sendBatch(ctx, retries)
+		if err == nil && len(retried) != len(retries) {
+			err = errors.New("Retried persisted queries did not get a result each")
+		}
+		if err != nil {
+			retryErr = err
+			retryResp = resp
+		} else {
+			for j, i := range missing {
+				batched[i] = &multiOpResult{data: retried[j], response: resp}
+			}
 		}
-		return results
 	}
 
 	// take the result from the query and turn it into something dataloader is okay with
-	for _, result := range queryResults {
+	for _, result := range batched {
 		results = append(results, &dataloader.Result{Data: result})
 	}
+	if retryErr != nil {
+		for _, i := range missing {
+			results[i] = &dataloader.Result{Data: &multiOpResult{response: retryResp}, Error: retryErr}
+		}
+	}
 
 	// return the results
 	return results
 }
+
+// failedBatchResults decodes the body of a batch that failed, if it can be, which holds either a result
+// for every operation or a single one for the whole batch
+func failedBatchResults(response []byte, size int, scalars *ScalarRegistry) []map[string]interface{} {
+	if len(response) == 0 {
+		return nil
+	}
+
+	queryResults := []map[string]interface{}{}
+	if err := unmarshalResponse(response, &queryResults, scalars); err == nil {
+		return queryResults
+	}
+
+	result := map[string]interface{}{}
+	if err := unmarshalResponse(response, &result, scalars); err != nil {
+		return nil
+	}
+	for i := 0; i < size; i++ {
+		queryResults = append(queryResults, result)
+	}
+	return queryResults
+}
+
+// sendBatch sends a list of operation payloads in a single request and decodes the list of their results
+func (q *MultiOpQueryer) sendBatch(ctx context.Context, requests []interface{}) ([]map[string]interface{}, *http.Response, error) {
+	payload, err := json.Marshal(requests)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// send the payload to the server
+	response, resp, err := q.queryer.sendQuery(ctx, payload)
+	if err != nil {
+		return failedBatchResults(response, len(requests), q.scalars), resp, err
+	}
+
+	// a place to handle each result
+	queryResults := []map[string]interface{}{}
+	if err := unmarshalResponse(response, &queryResults, q.scalars); err != nil {
+		return nil, resp, err
+	}
+	return queryResults, resp, nil
+}
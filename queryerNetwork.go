@@ -4,14 +4,29 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"net/url"
 
-	"github.com/go-viper/mapstructure/v2"
+	"github.com/vektah/gqlparser/v2/ast"
 )
 
+// DefaultMaxGetURLLength is the longest URL a query is sent with when WithGetQueries isn't given a limit.
+// Most browsers, proxies and CDNs accept at least this much.
+const DefaultMaxGetURLLength = 2048
+
 // SingleRequestQueryer sends the query to a url and returns the response
 type SingleRequestQueryer struct {
 	// internals for bundling queries
 	queryer *NetworkQueryer
+
+	// the longest URL to send a query with, if they are sent with GET
+	maxGetURLLength int
+
+	// the cache of query hashes, if the queries are sent as automatic persisted queries
+	persistedQueries *persistedQueries
+
+	// the custom scalars of the schema, if any
+	schema  *ast.Schema
+	scalars *ScalarRegistry
 }
 
 // NewSingleRequestQueryer returns a SingleRequestQueryer pointed to the given url
@@ -37,62 +52,192 @@ func (q *SingleRequestQueryer) WithHTTPClient(client *http.Client) Queryer {
 	return q
 }
 
+// WithScalars lets the user serialize variables and parse the responses with the custom scalars of the schema
+func (q *SingleRequestQueryer) WithScalars(schema *ast.Schema, scalars *ScalarRegistry) Queryer {
+	q.schema = schema
+	q.scalars = scalars
+
+	return q
+}
+
+// WithGetQueries makes the queryer send query operations as GET requests so they can be cached along the way.
+// Mutations, operations with file uploads and queries whose URL would be longer than maxURLLength are still
+// sent with POST. A maxURLLength of 0 uses DefaultMaxGetURLLength.
+func (q *SingleRequestQueryer) WithGetQueries(maxURLLength int) Queryer {
+	if maxURLLength <= 0 {
+		maxURLLength = DefaultMaxGetURLLength
+	}
+	q.maxGetURLLength = maxURLLength
+
+	return q
+}
+
+// WithAutomaticPersistedQueries makes the queryer send the hash of the query instead of the query itself, as long as
+// the server has seen it before. Unknown queries are sent again with their text for the server to remember.
+// The hashes of the most recently sent queries are cached, so repeated queries are only hashed once.
+func (q *SingleRequestQueryer) WithAutomaticPersistedQueries() Queryer {
+	q.persistedQueries = newPersistedQueries()
+
+	return q
+}
+
 func (q *SingleRequestQueryer) URL() string {
 	return q.queryer.URL
 }
 
 // Query sends the query to the designated url and returns the response.
 func (q *SingleRequestQueryer) Query(ctx context.Context, input *QueryInput, receiver interface{}) error {
+	// custom scalars are serialized before anything else looks at the variables
+	if q.scalars != nil {
+		serialized, err := q.scalars.SerializeVariables(q.schema, input)
+		if err != nil {
+			return err
+		}
+		input = serialized
+	}
+
 	// check if query contains attached files
 	uploadMap := extractFiles(input)
 
 	// the payload
-	payload, err := json.Marshal(map[string]interface{}{
+	request := map[string]interface{}{
 		"query":         input.Query,
 		"variables":     input.Variables,
 		"operationName": input.OperationName,
-	})
-	if err != nil {
-		return err
 	}
 
-	var response []byte
-	if uploadMap.NotEmpty() {
-		body, contentType, err := prepareMultipart(payload, uploadMap)
-
-		responseBody, err := q.queryer.SendMultipart(ctx, body, contentType)
-		if err != nil {
+	// files can only be read once so they are never part of a request that might be sent again
+	var result map[string]interface{}
+	var resp *http.Response
+	var err error
+	if q.persistedQueries != nil && !uploadMap.NotEmpty() {
+		result, resp, err = q.sendPersisted(ctx, input, request)
+	} else {
+		result, resp, err = q.send(ctx, input, request, uploadMap)
+	}
+	if err != nil {
+		// the response of a failed request can still be useful, to respect a rate limit for example
+		return decodeFailedResponse(q.queryer, result, resp, receiver, err)
+	}
+	if q.scalars != nil {
+		if err = parseResponseScalars(q.scalars, q.schema, input, result); err != nil {
 			return err
 		}
+	}
 
-		response = responseBody
+	return decodeResponse(q.queryer, result, resp, receiver)
+}
+
+// send sends the request to the designated url and decodes the response. The response of a request that
+// failed is decoded too if it can be, along with the error.
+func (q *SingleRequestQueryer) send(ctx context.Context, input *QueryInput, request map[string]interface{}, uploadMap *UploadMap) (map[string]interface{}, *http.Response, error) {
+	var response []byte
+	var resp *http.Response
+	var sendErr error
+	if params, ok := q.getParams(input, request, uploadMap); ok {
+		response, resp, sendErr = q.queryer.sendGet(ctx, params)
 	} else {
-		// send that query to the api and write the appropriate response to the receiver
-		responseBody, err := q.queryer.SendQuery(ctx, payload)
+		payload, err := json.Marshal(request)
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
 
-		response = responseBody
+		if uploadMap.NotEmpty() {
+			body, contentType, err := prepareMultipart(payload, uploadMap)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			response, resp, sendErr = q.queryer.sendMultipart(ctx, body, contentType)
+		} else {
+			// send that query to the api and write the appropriate response to the receiver
+			response, resp, sendErr = q.queryer.sendQuery(ctx, payload)
+		}
 	}
 
 	result := map[string]interface{}{}
-	if err = json.Unmarshal(response, &result); err != nil {
-		return err
+	if sendErr != nil {
+		if len(response) == 0 || unmarshalResponse(response, &result, q.scalars) != nil {
+			return nil, resp, sendErr
+		}
+		return result, resp, sendErr
+	}
+	if err := unmarshalResponse(response, &result, q.scalars); err != nil {
+		return nil, resp, err
+	}
+	return result, resp, nil
+}
+
+// sendPersisted sends the hash of the query in place of its text, which is only sent if the server asks for it
+func (q *SingleRequestQueryer) sendPersisted(ctx context.Context, input *QueryInput, request map[string]interface{}) (map[string]interface{}, *http.Response, error) {
+	extensions, ok := q.persistedQueries.extensions(input.Query)
+	if !ok {
+		return q.send(ctx, input, request, &UploadMap{})
+	}
+
+	hashed := map[string]interface{}{
+		"variables":     request["variables"],
+		"operationName": request["operationName"],
+		"extensions":    extensions,
+	}
+	result, resp, err := q.send(ctx, input, hashed, &UploadMap{})
+	if result == nil || !q.persistedQueries.missing(result) {
+		return result, resp, err
 	}
 
-	// assign the result under the data key to the receiver
-	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
-		TagName: "json",
-		Result:  receiver,
-	})
+	// the server needs the query to go with the hash, unless it turned out not to support them
+	if extensions, ok := q.persistedQueries.extensions(input.Query); ok {
+		request["extensions"] = extensions
+	}
+	return q.send(ctx, input, request, &UploadMap{})
+}
+
+// getParams returns the query string to send the request with, if it can be sent as a GET request
+func (q *SingleRequestQueryer) getParams(input *QueryInput, request map[string]interface{}, uploadMap *UploadMap) (url.Values, bool) {
+	if q.maxGetURLLength == 0 || uploadMap.NotEmpty() {
+		return nil, false
+	}
+
+	// only queries are safe to send with GET, anything we can't figure out goes through POST
+	_, operation, err := inputOperation(input)
+	if err != nil || operation.Operation != ast.Query {
+		return nil, false
+	}
+
+	params, err := getQueryParams(request)
 	if err != nil {
-		return err
+		return nil, false
+	}
+	target, err := q.queryer.getURL(params)
+	if err != nil || len(target) > q.maxGetURLLength {
+		return nil, false
 	}
-	if err = decoder.Decode(result["data"]); err != nil {
-		return err
+
+	return params, true
+}
+
+// getQueryParams encodes a request as a query string as described in
+// https://graphql.github.io/graphql-over-http/draft/#sec-GET
+func getQueryParams(request map[string]interface{}) (url.Values, error) {
+	params := url.Values{}
+	for _, key := range []string{"query", "operationName"} {
+		if value, ok := request[key].(string); ok && value != "" {
+			params.Set(key, value)
+		}
+	}
+
+	// the maps are sent as JSON
+	for _, key := range []string{"variables", "extensions"} {
+		value, ok := request[key].(map[string]interface{})
+		if !ok || len(value) == 0 {
+			continue
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		params.Set(key, string(encoded))
 	}
 
-	// finally extract errors, if any, and return them
-	return q.queryer.ExtractErrors(result) // TODO add unit tests!
+	return params, nil
 }
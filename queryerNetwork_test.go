@@ -1,12 +1,139 @@
 package graphql
 
 import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewSingleRequestQueryer(t *testing.T) {
 	// make sure that create a new query renderer saves the right URL
 	assert.Equal(t, "foo", NewSingleRequestQueryer("foo").queryer.URL)
 }
+
+func TestSingleRequestQueryer_getQueries(t *testing.T) {
+	t.Parallel()
+
+	for _, row := range []struct {
+		Message      string
+		URL          string
+		MaxURLLength int
+		Input        *QueryInput
+		Method       string
+		Params       url.Values
+	}{
+		{
+			Message: "query",
+			URL:     "http://localhost/graphql",
+			Input: &QueryInput{
+				Query:         "query user($id: ID!) { user(id: $id) { name } }",
+				OperationName: "user",
+				Variables:     map[string]interface{}{"id": "1"},
+			},
+			Method: http.MethodGet,
+			Params: url.Values{
+				"query":         []string{"query user($id: ID!) { user(id: $id) { name } }"},
+				"operationName": []string{"user"},
+				"variables":     []string{`{"id":"1"}`},
+			},
+		},
+		{
+			Message: "anonymous query without variables",
+			URL:     "http://localhost/graphql?token=abc",
+			Input:   &QueryInput{Query: "{ me { name } }"},
+			Method:  http.MethodGet,
+			Params: url.Values{
+				"query": []string{"{ me { name } }"},
+				"token": []string{"abc"},
+			},
+		},
+		{
+			Message: "mutation",
+			URL:     "http://localhost/graphql",
+			Input:   &QueryInput{Query: "mutation { logout }"},
+			Method:  http.MethodPost,
+		},
+		{
+			Message: "operation picked by name",
+			URL:     "http://localhost/graphql",
+			Input:   &QueryInput{Query: "query me { me { name } } mutation logout { logout }", OperationName: "logout"},
+			Method:  http.MethodPost,
+		},
+		{
+			Message:      "long url",
+			URL:          "http://localhost/graphql",
+			MaxURLLength: 64,
+			Input:        &QueryInput{Query: "{ me { name, friends { name, friends { name } } } }"},
+			Method:       http.MethodPost,
+		},
+		{
+			Message: "file upload",
+			URL:     "http://localhost/graphql",
+			Input: &QueryInput{
+				Query:     "query check($file: Upload!) { check(file: $file) }",
+				Variables: map[string]interface{}{"file": Upload{io.NopCloser(strings.NewReader("hello")), "hello.txt"}},
+			},
+			Method: http.MethodPost,
+		},
+		{
+			Message: "invalid query",
+			URL:     "http://localhost/graphql",
+			Input:   &QueryInput{Query: "{ me"},
+			Method:  http.MethodPost,
+		},
+	} {
+		row := row
+		t.Run(row.Message, func(t *testing.T) {
+			t.Parallel()
+
+			var sent *http.Request
+			queryer := NewSingleRequestQueryer(row.URL).WithHTTPClient(&http.Client{
+				Transport: roundTripFunc(func(req *http.Request) *http.Response {
+					sent = req
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(bytes.NewBufferString(`{"data": {"me": {"name": "John"}}}`)),
+						Header:     make(http.Header),
+					}
+				}),
+			}).(*SingleRequestQueryer).WithGetQueries(row.MaxURLLength)
+
+			result := map[string]interface{}{}
+			require.NoError(t, queryer.Query(context.Background(), row.Input, &result))
+			assert.Equal(t, map[string]interface{}{"me": map[string]interface{}{"name": "John"}}, result)
+
+			require.NotNil(t, sent)
+			assert.Equal(t, row.Method, sent.Method)
+			if row.Method == http.MethodGet {
+				assert.Equal(t, row.Params, sent.URL.Query())
+				assert.Nil(t, sent.Body)
+			}
+		})
+	}
+}
+
+func TestSingleRequestQueryer_postByDefault(t *testing.T) {
+	t.Parallel()
+
+	var method string
+	queryer := NewSingleRequestQueryer("http://localhost/graphql").WithHTTPClient(&http.Client{
+		Transport: roundTripFunc(func(req *http.Request) *http.Response {
+			method = req.Method
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(`{"data": {}}`)),
+				Header:     make(http.Header),
+			}
+		}),
+	})
+
+	require.NoError(t, queryer.Query(context.Background(), &QueryInput{Query: "{ me { name } }"}, &map[string]interface{}{}))
+	assert.Equal(t, http.MethodPost, method)
+}
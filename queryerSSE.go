@@ -0,0 +1,241 @@
+package graphql
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SSEQueryer runs operations, most notably subscriptions, with GraphQL over Server-Sent Events: every operation is
+// a request whose text/event-stream response carries its results as next events until a complete event.
+// A stream that breaks before it is complete is requested again with the id of the last event it received.
+type SSEQueryer struct {
+	// MaxReconnects is how many times in a row a broken stream is requested again before giving up
+	MaxReconnects int
+	// ReconnectDelay is how long to wait before requesting a broken stream again, unless the server
+	// asks for another delay with a retry field
+	ReconnectDelay time.Duration
+
+	// internals for sending the requests
+	queryer *NetworkQueryer
+}
+
+// NewSSEQueryer returns a SSEQueryer pointed to the given url that reconnects 3 times, after a second
+func NewSSEQueryer(url string) *SSEQueryer {
+	return &SSEQueryer{
+		MaxReconnects:  3,
+		ReconnectDelay: time.Second,
+		queryer:        &NetworkQueryer{URL: url},
+	}
+}
+
+// WithMiddlewares returns a queryer that will apply the provided middlewares to every request,
+// including the ones that resume a stream
+func (q *SSEQueryer) WithMiddlewares(mwares []NetworkMiddleware) Queryer {
+	q.queryer.Middlewares = mwares
+	return q
+}
+
+// WithHTTPClient lets the user configure the underlying http client being used. It should not have a timeout,
+// which would cut the streams short.
+func (q *SSEQueryer) WithHTTPClient(client *http.Client) Queryer {
+	q.queryer.Client = client
+	return q
+}
+
+func (q *SSEQueryer) URL() string {
+	return q.queryer.URL
+}
+
+// Subscribe sends the operation and returns a channel with every result the server streams back for it. The
+// channel is closed when the server completes the operation, when the context is done, or when the stream can't
+// be resumed, in which case the last result holds the error. A server that answers with a single JSON response
+// instead of a stream produces a single result.
+func (q *SSEQueryer) Subscribe(ctx context.Context, input *QueryInput) (<-chan *SubscriptionResult, error) {
+	payload, err := json.Marshal(map[string]interface{}{
+		"query":         input.Query,
+		"variables":     input.Variables,
+		"operationName": input.OperationName,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the first request fails the call, the next ones end up in the results
+	resp, err := q.queryer.SendEventStream(ctx, payload, "")
+	if err != nil {
+		return nil, err
+	}
+
+	sub := newSubscription(ctx, "")
+	go q.stream(ctx, payload, resp, sub)
+	return sub.results, nil
+}
+
+// Query sends the operation and writes its first result to the receiver
+func (q *SSEQueryer) Query(ctx context.Context, input *QueryInput, receiver interface{}) error {
+	// stop listening once we have what we need
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results, err := q.Subscribe(ctx, input)
+	if err != nil {
+		return err
+	}
+	result, ok := <-results
+	if !ok {
+		return errors.New("operation completed without a result")
+	}
+
+	if err := result.Decode(receiver); err != nil {
+		return err
+	}
+	return result.Err
+}
+
+// stream reads the results of an operation, requesting the stream again when it breaks
+func (q *SSEQueryer) stream(ctx context.Context, payload []byte, resp *http.Response, sub *subscription) {
+	lastEventID := ""
+	delay := q.ReconnectDelay
+	failures := 0
+
+	var err error
+	for {
+		// a failed request leaves us without a response to read
+		if resp != nil {
+			var completed bool
+			completed, err = q.readEvents(resp, sub, &lastEventID, &delay, &failures)
+			resp.Body.Close()
+			if completed {
+				sub.end(nil)
+				return
+			}
+		}
+
+		if ctx.Err() != nil {
+			sub.end(nil)
+			return
+		}
+		if failures >= q.MaxReconnects {
+			sub.end(&SubscriptionResult{Err: fmt.Errorf("event stream was lost: %w", err)})
+			return
+		}
+		failures++
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			sub.end(nil)
+			return
+		}
+		resp, err = q.queryer.SendEventStream(ctx, payload, lastEventID)
+	}
+}
+
+// readEvents delivers the results in the response until the stream is complete or breaks
+func (q *SSEQueryer) readEvents(resp *http.Response, sub *subscription, lastEventID *string, delay *time.Duration, failures *int) (bool, error) {
+	// servers can answer operations that aren't streamed with a plain response
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "text/event-stream" {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return false, err
+		}
+		sub.deliver(newSubscriptionResult(q.queryer, body))
+		return true, nil
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	for {
+		event, err := readServerSentEvent(reader)
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return false, err
+		}
+
+		if event.id != nil {
+			*lastEventID = *event.id
+		}
+		if event.retry > 0 {
+			*delay = event.retry
+		}
+
+		switch event.event {
+		case "next", "message", "":
+			if event.data == "" {
+				continue
+			}
+			// the stream is healthy again
+			*failures = 0
+			sub.deliver(newSubscriptionResult(q.queryer, []byte(event.data)))
+		case "complete":
+			return true, nil
+		}
+	}
+}
+
+// serverSentEvent is a single event of a text/event-stream
+type serverSentEvent struct {
+	event string
+	data  string
+	// id is nil if the event doesn't set it
+	id    *string
+	retry time.Duration
+}
+
+// readServerSentEvent reads the lines of the next event in the stream, as described in
+// https://html.spec.whatwg.org/multipage/server-sent-events.html#event-stream-interpretation
+func readServerSentEvent(reader *bufio.Reader) (*serverSentEvent, error) {
+	event := &serverSentEvent{}
+	data := []string{}
+	hasFields := false
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			// an event that isn't followed by a blank line is incomplete
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		// a blank line ends the event
+		if line == "" {
+			if !hasFields {
+				continue
+			}
+			event.data = strings.Join(data, "\n")
+			return event, nil
+		}
+
+		// lines starting with a colon are comments, often sent to keep the connection alive
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		hasFields = true
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event.event = value
+		case "data":
+			data = append(data, value)
+		case "id":
+			id := value
+			event.id = &id
+		case "retry":
+			if milliseconds, err := strconv.Atoi(value); err == nil {
+				event.retry = time.Duration(milliseconds) * time.Millisecond
+			}
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package graphql
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sseTestServer streams the numbers up to $to, one event each. When $drop is set, the first stream breaks
+// after that many events and the next ones pick up after the Last-Event-ID they are sent.
+type sseTestServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	headers []http.Header
+}
+
+func newSSETestServer(t *testing.T) *sseTestServer {
+	t.Helper()
+	server := &sseTestServer{}
+	server.Server = httptest.NewServer(http.HandlerFunc(server.handle))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (s *sseTestServer) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.headers = append(s.headers, r.Header.Clone())
+	requests := len(s.headers)
+	s.mu.Unlock()
+
+	var payload struct {
+		OperationName string
+		Variables     map[string]interface{}
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	switch payload.OperationName {
+	case "forbidden":
+		w.WriteHeader(http.StatusForbidden)
+		return
+	case "single":
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"data": {"single": true}, "errors": [{"message": "partial"}]}`)
+		return
+	}
+
+	to, _ := payload.Variables["to"].(float64)
+	drop, _ := payload.Variables["drop"].(float64)
+	start := 1
+	fmt.Sscan(r.Header.Get("Last-Event-ID"), &start)
+	if r.Header.Get("Last-Event-ID") != "" {
+		start++
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.WriteHeader(http.StatusOK)
+	writer := bufio.NewWriter(w)
+	flush := func() {
+		writer.Flush()
+		w.(http.Flusher).Flush()
+	}
+
+	fmt.Fprint(writer, ": keep-alive\n\nretry: 1\n\n")
+	for i := start; i <= int(to); i++ {
+		if requests == 1 && drop > 0 && i > int(drop) {
+			flush()
+			return
+		}
+		fmt.Fprintf(writer, "event: next\nid: %d\ndata: {\"data\":\ndata: {\"count\": %d}}\n\n", i, i)
+		flush()
+	}
+
+	if payload.OperationName == "hang" {
+		<-r.Context().Done()
+		return
+	}
+	fmt.Fprint(writer, "event: complete\r\ndata:\r\n\r\n")
+	flush()
+}
+
+func TestSSEQueryer_stream(t *testing.T) {
+	t.Parallel()
+	server := newSSETestServer(t)
+	queryer := NewSSEQueryer(server.URL)
+
+	results, err := queryer.Subscribe(context.Background(), &QueryInput{
+		Query:         "subscription count($to: Int) { count(to: $to) }",
+		OperationName: "count",
+		Variables:     map[string]interface{}{"to": 3},
+	})
+	require.NoError(t, err)
+
+	collected := collectSubscriptionResults(t, results)
+	require.Len(t, collected, 3)
+	for i, result := range collected {
+		assert.NoError(t, result.Err)
+		var decoded struct{ Count int }
+		require.NoError(t, result.Decode(&decoded))
+		assert.Equal(t, i+1, decoded.Count)
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	require.Len(t, server.headers, 1)
+	assert.Equal(t, "text/event-stream, application/json", server.headers[0].Get("Accept"))
+	assert.Equal(t, "application/json", server.headers[0].Get("Content-Type"))
+}
+
+func TestSSEQueryer_reconnect(t *testing.T) {
+	t.Parallel()
+	server := newSSETestServer(t)
+	queryer := NewSSEQueryer(server.URL)
+
+	results, err := queryer.Subscribe(context.Background(), &QueryInput{
+		Query:         "subscription count($to: Int) { count(to: $to) }",
+		OperationName: "count",
+		Variables:     map[string]interface{}{"to": 4, "drop": 2},
+	})
+	require.NoError(t, err)
+
+	// the broken stream is resumed after the last event it sent
+	counts := []int{}
+	for _, result := range collectSubscriptionResults(t, results) {
+		require.NoError(t, result.Err)
+		var decoded struct{ Count int }
+		require.NoError(t, result.Decode(&decoded))
+		counts = append(counts, decoded.Count)
+	}
+	assert.Equal(t, []int{1, 2, 3, 4}, counts)
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	require.Len(t, server.headers, 2)
+	assert.Equal(t, "", server.headers[0].Get("Last-Event-ID"))
+	assert.Equal(t, "2", server.headers[1].Get("Last-Event-ID"))
+}
+
+func TestSSEQueryer_streamLost(t *testing.T) {
+	t.Parallel()
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) > 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: next\ndata: {\"data\": {\"count\": 1}}\n\n")
+	}))
+	defer server.Close()
+
+	queryer := NewSSEQueryer(server.URL)
+	queryer.ReconnectDelay = time.Millisecond
+	queryer.MaxReconnects = 2
+
+	results, err := queryer.Subscribe(context.Background(), &QueryInput{Query: "subscription { count }"})
+	require.NoError(t, err)
+
+	collected := collectSubscriptionResults(t, results)
+	require.Len(t, collected, 2)
+	assert.NoError(t, collected[0].Err)
+	assert.EqualError(t, collected[1].Err, "event stream was lost: response was not successful with status code: 503")
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+}
+
+func TestSSEQueryer_query(t *testing.T) {
+	t.Parallel()
+	server := newSSETestServer(t)
+	queryer := NewSSEQueryer(server.URL)
+	ctx := context.Background()
+
+	// the first result of a stream
+	var result struct{ Count int }
+	require.NoError(t, queryer.Query(ctx, &QueryInput{
+		Query:         "subscription count($to: Int) { count(to: $to) }",
+		OperationName: "count",
+		Variables:     map[string]interface{}{"to": 2},
+	}, &result))
+	assert.Equal(t, 1, result.Count)
+
+	// a plain JSON response is decoded like any other query
+	var single map[string]interface{}
+	err := queryer.Query(ctx, &QueryInput{Query: "query single { single }", OperationName: "single"}, &single)
+	assert.EqualError(t, err, "partial")
+	assert.Equal(t, map[string]interface{}{"single": true}, single)
+
+	// HTTP errors are returned right away
+	_, err = queryer.Subscribe(ctx, &QueryInput{Query: "subscription forbidden { a }", OperationName: "forbidden"})
+	assert.EqualError(t, err, "response was not successful with status code: 403")
+}
+
+func TestSSEQueryer_cancel(t *testing.T) {
+	t.Parallel()
+	server := newSSETestServer(t)
+	queryer := NewSSEQueryer(server.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	results, err := queryer.Subscribe(ctx, &QueryInput{
+		Query:         "subscription hang($to: Int) { count(to: $to) }",
+		OperationName: "hang",
+		Variables:     map[string]interface{}{"to": 1},
+	})
+	require.NoError(t, err)
+
+	result := <-results
+	require.NotNil(t, result)
+	assert.Equal(t, map[string]interface{}{"count": float64(1)}, result.Data)
+
+	// cancelling the context ends the stream without reconnecting
+	cancel()
+	collectSubscriptionResults(t, results)
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	assert.Len(t, server.headers, 1)
+}
+
+func TestSSEQueryer_hooks(t *testing.T) {
+	t.Parallel()
+
+	// the client answers every request itself
+	client := &http.Client{
+		Transport: roundTripFunc(func(req *http.Request) *http.Response {
+			if req.Header.Get("Authorization") != "Bearer token" {
+				return &http.Response{StatusCode: http.StatusUnauthorized, Body: io.NopCloser(strings.NewReader("")), Header: http.Header{}}
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"text/event-stream; charset=utf-8"}},
+				Body:       io.NopCloser(strings.NewReader("event: next\ndata: {\"data\": {\"count\": 1}}\n\nevent: complete\n\n")),
+			}
+		}),
+	}
+
+	queryer := NewSSEQueryer("http://localhost/graphql").WithHTTPClient(client).(*SSEQueryer).WithMiddlewares([]NetworkMiddleware{
+		func(req *http.Request) error {
+			req.Header.Set("Authorization", "Bearer token")
+			return nil
+		},
+	})
+	assert.Equal(t, "http://localhost/graphql", queryer.(*SSEQueryer).URL())
+
+	var result struct{ Count int }
+	require.NoError(t, queryer.Query(context.Background(), &QueryInput{Query: "subscription { count }"}, &result))
+	assert.Equal(t, 1, result.Count)
+
+	// middleware errors are returned
+	_, err := NewSSEQueryer("http://localhost/graphql").WithMiddlewares([]NetworkMiddleware{
+		func(req *http.Request) error { return errors.New("no token") },
+	}).(*SSEQueryer).Subscribe(context.Background(), &QueryInput{Query: "subscription { count }"})
+	assert.EqualError(t, err, "no token")
+}
+
+func TestReadServerSentEvent(t *testing.T) {
+	t.Parallel()
+	reader := bufio.NewReader(strings.NewReader(":comment\n\nevent: next\r\nid: 7\r\nretry: 50\r\ndata: a\r\ndata:b\r\nunknown: c\r\n\r\nid\ndata\n\ndata: trailing"))
+
+	event, err := readServerSentEvent(reader)
+	require.NoError(t, err)
+	require.NotNil(t, event.id)
+	assert.Equal(t, "next", event.event)
+	assert.Equal(t, "7", *event.id)
+	assert.Equal(t, 50*time.Millisecond, event.retry)
+	assert.Equal(t, "a\nb", event.data)
+
+	// an empty id resets the last one
+	event, err = readServerSentEvent(reader)
+	require.NoError(t, err)
+	require.NotNil(t, event.id)
+	assert.Equal(t, "", *event.id)
+	assert.Equal(t, "", event.data)
+
+	// an event cut short is not dispatched
+	_, err = readServerSentEvent(reader)
+	assert.Error(t, err)
+}
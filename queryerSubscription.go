@@ -0,0 +1,557 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-viper/mapstructure/v2"
+	"golang.org/x/net/websocket"
+)
+
+// subscriptionProtocol is the websocket subprotocol described at
+// https://github.com/enisdenjo/graphql-ws/blob/master/PROTOCOL.md
+const subscriptionProtocol = "graphql-transport-ws"
+
+// the types of the messages of the graphql-transport-ws protocol
+const (
+	subscriptionConnectionInit = "connection_init"
+	subscriptionConnectionAck  = "connection_ack"
+	subscriptionPing           = "ping"
+	subscriptionPong           = "pong"
+	subscriptionSubscribe      = "subscribe"
+	subscriptionNext           = "next"
+	subscriptionError          = "error"
+	subscriptionComplete       = "complete"
+)
+
+// subscriptionMessage is a single message of the graphql-transport-ws protocol
+type subscriptionMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// SubscriptionResult is one of the results the server sends for an operation
+type SubscriptionResult struct {
+	Data       map[string]interface{}
+	Extensions map[string]interface{}
+	// Err holds the errors of the result as an ErrorList, or the error that ended the subscription
+	Err error
+}
+
+// Decode assigns the data of the result to the receiver the same way the other queryers do
+func (r *SubscriptionResult) Decode(receiver interface{}) error {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		TagName: "json",
+		Result:  receiver,
+	})
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(r.Data)
+}
+
+// newSubscriptionResult decodes a single result of an operation, like the payload of a next message
+func newSubscriptionResult(queryer *NetworkQueryer, payload []byte) *SubscriptionResult {
+	response := map[string]interface{}{}
+	if err := json.Unmarshal(payload, &response); err != nil {
+		return &SubscriptionResult{Err: err}
+	}
+
+	result := &SubscriptionResult{Err: queryer.ExtractErrors(response)}
+	if data, ok := response["data"].(map[string]interface{}); ok {
+		result.Data = data
+	}
+	if extensions, ok := response["extensions"].(map[string]interface{}); ok {
+		result.Extensions = extensions
+	}
+	return result
+}
+
+// SubscriptionQueryer runs operations, most notably subscriptions, over a websocket that speaks the
+// graphql-transport-ws protocol. Every operation shares a single connection, which is opened when the first one
+// starts and closed once the last one is over.
+type SubscriptionQueryer struct {
+	// ConnectionParams is sent to the server as the payload of the connection_init message
+	ConnectionParams map[string]interface{}
+	// AckTimeout is how long the server has to acknowledge a new connection. It defaults to 10 seconds.
+	AckTimeout time.Duration
+
+	// the url and the middlewares for the handshake request
+	queryer *NetworkQueryer
+
+	mu   sync.Mutex
+	conn *subscriptionConnection
+	// dial is the connection being opened, if any, which is done without holding mu
+	dial *subscriptionDial
+}
+
+// subscriptionDial is a connection being opened, which the operations that start in the meantime wait for
+type subscriptionDial struct {
+	ctx  context.Context
+	done chan struct{}
+	err  error
+}
+
+// NewSubscriptionQueryer returns a SubscriptionQueryer pointed to the given url. Both ws(s):// and http(s)://
+// urls are accepted.
+func NewSubscriptionQueryer(url string) *SubscriptionQueryer {
+	return &SubscriptionQueryer{
+		queryer: &NetworkQueryer{URL: url},
+	}
+}
+
+// WithMiddlewares returns a queryer that will apply the provided middlewares to the handshake request
+// of its connections
+func (q *SubscriptionQueryer) WithMiddlewares(mwares []NetworkMiddleware) Queryer {
+	q.queryer.Middlewares = mwares
+	return q
+}
+
+func (q *SubscriptionQueryer) URL() string {
+	return q.queryer.URL
+}
+
+// Subscribe starts the operation and returns a channel with every result the server sends for it. The channel is
+// closed when the server completes the operation, when the context is done, or when the connection is lost, in
+// which case the last result holds the error. Results are queued until they are read.
+func (q *SubscriptionQueryer) Subscribe(ctx context.Context, input *QueryInput) (<-chan *SubscriptionResult, error) {
+	payload, err := json.Marshal(map[string]interface{}{
+		"query":         input.Query,
+		"variables":     input.Variables,
+		"operationName": input.OperationName,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	conn, sub, err := q.subscribe(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := conn.send(&subscriptionMessage{ID: sub.id, Type: subscriptionSubscribe, Payload: payload}); err != nil {
+		q.stop(conn, sub, nil, false)
+		return nil, err
+	}
+
+	// tell the server when the caller is no longer interested
+	go func() {
+		select {
+		case <-ctx.Done():
+			q.stop(conn, sub, nil, true)
+		case <-sub.stopped:
+		}
+	}()
+
+	return sub.results, nil
+}
+
+// subscribe adds an operation to the current connection, opening a new one if there is none. Opening a
+// connection can take a while so it is done without holding the lock, the operations that start in the
+// meantime wait for it as long as their context allows.
+func (q *SubscriptionQueryer) subscribe(ctx context.Context) (*subscriptionConnection, *subscription, error) {
+	for {
+		q.mu.Lock()
+		if conn := q.conn; conn != nil {
+			sub, err := conn.add(ctx)
+			q.mu.Unlock()
+			return conn, sub, err
+		}
+
+		if dial := q.dial; dial != nil {
+			q.mu.Unlock()
+			select {
+			case <-dial.done:
+			case <-ctx.Done():
+				return nil, nil, ctx.Err()
+			}
+			// a connection that failed because whoever opened it gave up can be tried again
+			if dial.err != nil && dial.ctx.Err() == nil {
+				return nil, nil, dial.err
+			}
+			continue
+		}
+
+		dial := &subscriptionDial{ctx: ctx, done: make(chan struct{})}
+		q.dial = dial
+		q.mu.Unlock()
+
+		conn, err := q.connect(ctx)
+		q.mu.Lock()
+		q.dial = nil
+		if err == nil {
+			q.conn = conn
+		}
+		q.mu.Unlock()
+		dial.err = err
+		close(dial.done)
+
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+// Query runs the operation and writes its first result to the receiver, which makes it possible to send queries
+// and mutations over the same connection as the subscriptions
+func (q *SubscriptionQueryer) Query(ctx context.Context, input *QueryInput, receiver interface{}) error {
+	// stop listening once we have what we need
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results, err := q.Subscribe(ctx, input)
+	if err != nil {
+		return err
+	}
+	result, ok := <-results
+	if !ok {
+		return errors.New("operation completed without a result")
+	}
+
+	if err := result.Decode(receiver); err != nil {
+		return err
+	}
+	return result.Err
+}
+
+// Close closes the connection, if any, which closes the channels of every running operation
+func (q *SubscriptionQueryer) Close() error {
+	q.mu.Lock()
+	conn := q.conn
+	q.conn = nil
+	q.mu.Unlock()
+
+	if conn == nil {
+		return nil
+	}
+	subs, _ := conn.shutdown()
+	for _, sub := range subs {
+		sub.end(nil)
+	}
+	return nil
+}
+
+// connect opens a connection and waits for the server to acknowledge it
+func (q *SubscriptionQueryer) connect(ctx context.Context) (*subscriptionConnection, error) {
+	// the middlewares get to see the handshake request before we send it
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, q.queryer.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, mware := range q.queryer.Middlewares {
+		if err := mware(req); err != nil {
+			return nil, err
+		}
+	}
+
+	location := *req.URL
+	origin := url.URL{Scheme: "http", Host: location.Host}
+	switch location.Scheme {
+	case "http":
+		location.Scheme = "ws"
+	case "https", "wss":
+		location.Scheme = "wss"
+		origin.Scheme = "https"
+	}
+	config, err := websocket.NewConfig(location.String(), origin.String())
+	if err != nil {
+		return nil, err
+	}
+	config.Protocol = []string{subscriptionProtocol}
+	config.Header = req.Header
+
+	ws, err := config.DialContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conn := &subscriptionConnection{ws: ws, subscriptions: map[string]*subscription{}}
+
+	timeout := q.AckTimeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	if err := conn.init(ctx, q.ConnectionParams, timeout); err != nil {
+		ws.Close()
+		return nil, err
+	}
+
+	go q.read(conn)
+	return conn, nil
+}
+
+// read handles the messages the server sends over the connection until it is closed
+func (q *SubscriptionQueryer) read(conn *subscriptionConnection) {
+	for {
+		var message subscriptionMessage
+		if err := websocket.JSON.Receive(conn.ws, &message); err != nil {
+			q.fail(conn, err)
+			return
+		}
+
+		switch message.Type {
+		case subscriptionNext:
+			if sub := conn.subscription(message.ID); sub != nil {
+				sub.deliver(newSubscriptionResult(q.queryer, message.Payload))
+			}
+		case subscriptionError:
+			if sub := conn.subscription(message.ID); sub != nil {
+				// the payload is the list of errors that prevented the operation from running
+				errs := []interface{}{}
+				if err := json.Unmarshal(message.Payload, &errs); err != nil {
+					q.stop(conn, sub, &SubscriptionResult{Err: err}, false)
+					continue
+				}
+				q.stop(conn, sub, &SubscriptionResult{Err: q.queryer.ExtractErrors(map[string]interface{}{"errors": errs})}, false)
+			}
+		case subscriptionComplete:
+			if sub := conn.subscription(message.ID); sub != nil {
+				q.stop(conn, sub, nil, false)
+			}
+		case subscriptionPing:
+			_ = conn.send(&subscriptionMessage{Type: subscriptionPong})
+		}
+	}
+}
+
+// stop ends the subscription with the given result and closes the connection if it was the last one
+func (q *SubscriptionQueryer) stop(conn *subscriptionConnection, sub *subscription, result *SubscriptionResult, notifyServer bool) {
+	if !sub.end(result) {
+		return
+	}
+	if notifyServer {
+		_ = conn.send(&subscriptionMessage{ID: sub.id, Type: subscriptionComplete})
+	}
+
+	q.mu.Lock()
+	remaining := conn.remove(sub)
+	if remaining == 0 && q.conn == conn {
+		q.conn = nil
+	}
+	q.mu.Unlock()
+
+	if remaining == 0 {
+		conn.shutdown()
+	}
+}
+
+// fail ends every subscription of a connection that was lost
+func (q *SubscriptionQueryer) fail(conn *subscriptionConnection, err error) {
+	q.mu.Lock()
+	if q.conn == conn {
+		q.conn = nil
+	}
+	q.mu.Unlock()
+
+	subs, ok := conn.shutdown()
+	if !ok {
+		// we closed the connection ourselves
+		return
+	}
+	for _, sub := range subs {
+		sub.end(&SubscriptionResult{Err: fmt.Errorf("subscription connection was lost: %w", err)})
+	}
+}
+
+// subscriptionConnection is a websocket shared by several operations
+type subscriptionConnection struct {
+	ws      *websocket.Conn
+	writeMu sync.Mutex
+
+	mu            sync.Mutex
+	subscriptions map[string]*subscription
+	nextID        int
+	closed        bool
+}
+
+// init sends the connection_init message and waits for the server to acknowledge it
+func (c *subscriptionConnection) init(ctx context.Context, params map[string]interface{}, timeout time.Duration) error {
+	message := &subscriptionMessage{Type: subscriptionConnectionInit}
+	if params != nil {
+		payload, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		message.Payload = payload
+	}
+	if err := c.send(message); err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := c.ws.SetReadDeadline(deadline); err != nil {
+		return err
+	}
+	defer c.ws.SetReadDeadline(time.Time{})
+
+	for {
+		var response subscriptionMessage
+		if err := websocket.JSON.Receive(c.ws, &response); err != nil {
+			return fmt.Errorf("waiting for %s: %w", subscriptionConnectionAck, err)
+		}
+		switch response.Type {
+		case subscriptionConnectionAck:
+			return nil
+		case subscriptionPing:
+			if err := c.send(&subscriptionMessage{Type: subscriptionPong}); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("expected %s, got %s", subscriptionConnectionAck, response.Type)
+		}
+	}
+}
+
+func (c *subscriptionConnection) send(message *subscriptionMessage) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return websocket.JSON.Send(c.ws, message)
+}
+
+// add registers a new subscription with a unique id
+func (c *subscriptionConnection) add(ctx context.Context) (*subscription, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil, errors.New("subscription connection is closed")
+	}
+
+	c.nextID++
+	sub := newSubscription(ctx, strconv.Itoa(c.nextID))
+	c.subscriptions[sub.id] = sub
+	return sub, nil
+}
+
+func (c *subscriptionConnection) subscription(id string) *subscription {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.subscriptions[id]
+}
+
+// remove forgets the subscription and returns the number of subscriptions left
+func (c *subscriptionConnection) remove(sub *subscription) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.subscriptions, sub.id)
+	return len(c.subscriptions)
+}
+
+// shutdown closes the websocket and returns the subscriptions that were still running, or false if the
+// connection had already been shut down
+func (c *subscriptionConnection) shutdown() ([]*subscription, bool) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil, false
+	}
+	c.closed = true
+	subs := []*subscription{}
+	for _, sub := range c.subscriptions {
+		subs = append(subs, sub)
+	}
+	c.subscriptions = map[string]*subscription{}
+	c.mu.Unlock()
+
+	c.ws.Close()
+	return subs, true
+}
+
+// subscription is a single operation running over a connection
+type subscription struct {
+	id      string
+	ctx     context.Context
+	results chan *SubscriptionResult
+	// stopped is closed once the subscription has ended
+	stopped chan struct{}
+
+	// the results waiting for the subscriber, so a slow one doesn't hold back the connection
+	mu    sync.Mutex
+	queue []*SubscriptionResult
+	ended bool
+	wake  chan struct{}
+}
+
+func newSubscription(ctx context.Context, id string) *subscription {
+	sub := &subscription{
+		id:      id,
+		ctx:     ctx,
+		results: make(chan *SubscriptionResult),
+		stopped: make(chan struct{}),
+		wake:    make(chan struct{}, 1),
+	}
+	go sub.pump()
+	return sub
+}
+
+// deliver queues a result for the subscriber
+func (s *subscription) deliver(result *SubscriptionResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	s.queue = append(s.queue, result)
+	s.signal()
+}
+
+// end queues the last result, if any, after which the channel is closed. It returns false if the subscription
+// had already ended.
+func (s *subscription) end(result *SubscriptionResult) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return false
+	}
+	s.ended = true
+	if result != nil {
+		s.queue = append(s.queue, result)
+	}
+	close(s.stopped)
+	s.signal()
+	return true
+}
+
+func (s *subscription) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// pump passes the queued results to the subscriber until the subscription ends or the subscriber loses interest
+func (s *subscription) pump() {
+	defer close(s.results)
+	for {
+		s.mu.Lock()
+		queue, ended := s.queue, s.ended
+		s.queue = nil
+		s.mu.Unlock()
+
+		for _, result := range queue {
+			select {
+			case s.results <- result:
+			case <-s.ctx.Done():
+				return
+			}
+		}
+		if ended {
+			return
+		}
+
+		select {
+		case <-s.wake:
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,420 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+// subscriptionTestServer is a minimal graphql-transport-ws server. The operations are named after what they do:
+// "count" sends the numbers up to $to, "tick" sends a number every millisecond until it is completed,
+// "fail" answers with an error message and "drop" closes the connection.
+type subscriptionTestServer struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	connections int
+	open        int
+	headers     []http.Header
+	params      []map[string]interface{}
+	completed   []string
+	pongs       int
+	// hold, when set, keeps the handshakes waiting until it is closed
+	hold chan struct{}
+}
+
+func newSubscriptionTestServer(t *testing.T) *subscriptionTestServer {
+	t.Helper()
+	server := &subscriptionTestServer{}
+	server.Server = httptest.NewServer(websocket.Server{
+		Handshake: func(config *websocket.Config, req *http.Request) error {
+			if !containsString(config.Protocol, subscriptionProtocol) {
+				return http.ErrNotSupported
+			}
+			config.Protocol = []string{subscriptionProtocol}
+
+			server.mu.Lock()
+			hold := server.hold
+			server.headers = append(server.headers, req.Header.Clone())
+			server.mu.Unlock()
+			if hold != nil {
+				<-hold
+			}
+			return nil
+		},
+		Handler: server.handle,
+	})
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (s *subscriptionTestServer) handle(ws *websocket.Conn) {
+	s.mu.Lock()
+	s.connections++
+	s.open++
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.open--
+		s.mu.Unlock()
+	}()
+
+	var writeMu sync.Mutex
+	send := func(message *subscriptionMessage) {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		_ = websocket.JSON.Send(ws, message)
+	}
+
+	var init subscriptionMessage
+	if err := websocket.JSON.Receive(ws, &init); err != nil || init.Type != subscriptionConnectionInit {
+		return
+	}
+	params := map[string]interface{}{}
+	_ = json.Unmarshal(init.Payload, &params)
+	s.mu.Lock()
+	s.params = append(s.params, params)
+	s.mu.Unlock()
+
+	send(&subscriptionMessage{Type: subscriptionConnectionAck})
+	send(&subscriptionMessage{Type: subscriptionPing})
+
+	// the operations that are still running, by id
+	running := map[string]chan struct{}{}
+	var runningMu sync.Mutex
+	finish := func(id string) bool {
+		runningMu.Lock()
+		defer runningMu.Unlock()
+		stop, ok := running[id]
+		if ok {
+			close(stop)
+			delete(running, id)
+		}
+		return ok
+	}
+
+	for {
+		var message subscriptionMessage
+		if err := websocket.JSON.Receive(ws, &message); err != nil {
+			return
+		}
+
+		switch message.Type {
+		case subscriptionPong:
+			s.mu.Lock()
+			s.pongs++
+			s.mu.Unlock()
+
+		case subscriptionComplete:
+			if finish(message.ID) {
+				s.mu.Lock()
+				s.completed = append(s.completed, message.ID)
+				s.mu.Unlock()
+			}
+
+		case subscriptionSubscribe:
+			var payload struct {
+				OperationName string
+				Variables     map[string]interface{}
+			}
+			_ = json.Unmarshal(message.Payload, &payload)
+
+			stop := make(chan struct{})
+			runningMu.Lock()
+			running[message.ID] = stop
+			runningMu.Unlock()
+
+			id := message.ID
+			next := func(data string) {
+				send(&subscriptionMessage{ID: id, Type: subscriptionNext, Payload: json.RawMessage(data)})
+			}
+
+			switch payload.OperationName {
+			case "count":
+				go func() {
+					to, _ := payload.Variables["to"].(float64)
+					for i := 1; i <= int(to); i++ {
+						next(`{"data": {"count": ` + strings.Repeat("1", i) + `}}`)
+					}
+					if finish(id) {
+						send(&subscriptionMessage{ID: id, Type: subscriptionComplete})
+					}
+				}()
+			case "tick":
+				go func() {
+					for {
+						select {
+						case <-stop:
+							return
+						case <-time.After(time.Millisecond):
+							next(`{"data": {"tick": true}, "errors": [{"message": "slow"}], "extensions": {"cost": 1}}`)
+						}
+					}
+				}()
+			case "fail":
+				finish(id)
+				send(&subscriptionMessage{ID: id, Type: subscriptionError, Payload: json.RawMessage(`[{"message": "unknown field"}]`)})
+			case "drop":
+				return
+			}
+		}
+	}
+}
+
+func (s *subscriptionTestServer) stats() (connections int, open int, completed []string, pongs int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.connections, s.open, append([]string{}, s.completed...), s.pongs
+}
+
+// collectSubscriptionResults reads the channel until it is closed
+func collectSubscriptionResults(t *testing.T, results <-chan *SubscriptionResult) []*SubscriptionResult {
+	t.Helper()
+	collected := []*SubscriptionResult{}
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case result, ok := <-results:
+			if !ok {
+				return collected
+			}
+			collected = append(collected, result)
+		case <-timeout:
+			t.Fatal("subscription did not end")
+			return nil
+		}
+	}
+}
+
+func TestSubscriptionQueryer_multiplexes(t *testing.T) {
+	t.Parallel()
+	server := newSubscriptionTestServer(t)
+	queryer := NewSubscriptionQueryer(server.URL)
+	queryer.ConnectionParams = map[string]interface{}{"token": "secret"}
+	ctx := context.Background()
+
+	first, err := queryer.Subscribe(ctx, &QueryInput{
+		Query:         "subscription count($to: Int) { count(to: $to) }",
+		OperationName: "count",
+		Variables:     map[string]interface{}{"to": 3},
+	})
+	require.NoError(t, err)
+	second, err := queryer.Subscribe(ctx, &QueryInput{
+		Query:         "subscription count($to: Int) { count(to: $to) }",
+		OperationName: "count",
+		Variables:     map[string]interface{}{"to": 2},
+	})
+	require.NoError(t, err)
+
+	var firstResults, secondResults []*SubscriptionResult
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		firstResults = collectSubscriptionResults(t, first)
+	}()
+	go func() {
+		defer wg.Done()
+		secondResults = collectSubscriptionResults(t, second)
+	}()
+	wg.Wait()
+
+	require.Len(t, firstResults, 3)
+	require.Len(t, secondResults, 2)
+	for i, result := range firstResults {
+		assert.NoError(t, result.Err)
+		var decoded struct{ Count int }
+		require.NoError(t, result.Decode(&decoded))
+		assert.Equal(t, []int{1, 11, 111}[i], decoded.Count)
+	}
+
+	// both ran over the same connection, which is closed once they are over
+	require.Eventually(t, func() bool {
+		_, open, _, _ := server.stats()
+		return open == 0
+	}, time.Second, time.Millisecond)
+	connections, _, _, pongs := server.stats()
+	assert.Equal(t, 1, connections)
+	assert.Equal(t, 1, pongs)
+	server.mu.Lock()
+	assert.Equal(t, []map[string]interface{}{{"token": "secret"}}, server.params)
+	server.mu.Unlock()
+
+	// the next operation opens a new connection
+	var result struct{ Count int }
+	require.NoError(t, queryer.Query(ctx, &QueryInput{
+		Query:         "subscription count($to: Int) { count(to: $to) }",
+		OperationName: "count",
+		Variables:     map[string]interface{}{"to": 1},
+	}, &result))
+	assert.Equal(t, 1, result.Count)
+	connections, _, _, _ = server.stats()
+	assert.Equal(t, 2, connections)
+}
+
+func TestSubscriptionQueryer_cancel(t *testing.T) {
+	t.Parallel()
+	server := newSubscriptionTestServer(t)
+	queryer := NewSubscriptionQueryer(server.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ticks, err := queryer.Subscribe(ctx, &QueryInput{Query: "subscription tick { tick }", OperationName: "tick"})
+	require.NoError(t, err)
+	other, err := queryer.Subscribe(context.Background(), &QueryInput{Query: "subscription tick { tick }", OperationName: "tick"})
+	require.NoError(t, err)
+
+	result := <-ticks
+	assert.Equal(t, map[string]interface{}{"tick": true}, result.Data)
+	assert.Equal(t, map[string]interface{}{"cost": float64(1)}, result.Extensions)
+	assert.EqualError(t, result.Err, "slow")
+
+	// cancelling the context completes the subscription on both ends
+	cancel()
+	collectSubscriptionResults(t, ticks)
+	require.Eventually(t, func() bool {
+		_, _, completed, _ := server.stats()
+		return len(completed) == 1
+	}, time.Second, time.Millisecond)
+	_, _, completed, _ := server.stats()
+	assert.Equal(t, []string{"1"}, completed)
+
+	// without touching the other subscription
+	_, open, _, _ := server.stats()
+	assert.Equal(t, 1, open)
+	_, ok := <-other
+	assert.True(t, ok)
+
+	// closing the queryer ends everything
+	require.NoError(t, queryer.Close())
+	collectSubscriptionResults(t, other)
+	require.Eventually(t, func() bool {
+		_, open, _, _ := server.stats()
+		return open == 0
+	}, time.Second, time.Millisecond)
+}
+
+func TestSubscriptionQueryer_errors(t *testing.T) {
+	t.Parallel()
+	server := newSubscriptionTestServer(t)
+	queryer := NewSubscriptionQueryer(server.URL)
+	ctx := context.Background()
+
+	// an operation the server rejects
+	results, err := queryer.Subscribe(ctx, &QueryInput{Query: "subscription fail { nope }", OperationName: "fail"})
+	require.NoError(t, err)
+	collected := collectSubscriptionResults(t, results)
+	require.Len(t, collected, 1)
+	assert.EqualError(t, collected[0].Err, "unknown field")
+
+	var receiver map[string]interface{}
+	assert.EqualError(t, queryer.Query(ctx, &QueryInput{Query: "query fail { nope }", OperationName: "fail"}, &receiver), "unknown field")
+
+	// a connection that goes away
+	ticks, err := queryer.Subscribe(ctx, &QueryInput{Query: "subscription tick { tick }", OperationName: "tick"})
+	require.NoError(t, err)
+	drop, err := queryer.Subscribe(ctx, &QueryInput{Query: "subscription drop { drop }", OperationName: "drop"})
+	require.NoError(t, err)
+
+	for _, results := range []<-chan *SubscriptionResult{ticks, drop} {
+		collected = collectSubscriptionResults(t, results)
+		require.NotEmpty(t, collected)
+		assert.Contains(t, collected[len(collected)-1].Err.Error(), "subscription connection was lost")
+	}
+}
+
+func TestSubscriptionQueryer_middlewares(t *testing.T) {
+	t.Parallel()
+	server := newSubscriptionTestServer(t)
+	queryer := NewSubscriptionQueryer(strings.Replace(server.URL, "http://", "ws://", 1)).WithMiddlewares([]NetworkMiddleware{
+		func(req *http.Request) error {
+			req.Header.Set("Authorization", "Bearer token")
+			return nil
+		},
+	})
+
+	var result struct{ Count int }
+	require.NoError(t, queryer.Query(context.Background(), &QueryInput{
+		Query:         "subscription count($to: Int) { count(to: $to) }",
+		OperationName: "count",
+		Variables:     map[string]interface{}{"to": 1},
+	}, &result))
+	assert.Equal(t, 1, result.Count)
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	require.Len(t, server.headers, 1)
+	assert.Equal(t, "Bearer token", server.headers[0].Get("Authorization"))
+	assert.Equal(t, subscriptionProtocol, server.headers[0].Get("Sec-Websocket-Protocol"))
+}
+
+func TestSubscriptionQueryer_slowConnection(t *testing.T) {
+	t.Parallel()
+	server := newSubscriptionTestServer(t)
+	hold := make(chan struct{})
+	server.mu.Lock()
+	server.hold = hold
+	server.mu.Unlock()
+	queryer := NewSubscriptionQueryer(server.URL)
+	input := &QueryInput{
+		Query:         "subscription count($to: Int) { count(to: $to) }",
+		OperationName: "count",
+		Variables:     map[string]interface{}{"to": 1},
+	}
+
+	// the first operation opens the connection
+	first := make(chan error, 1)
+	go func() {
+		var result struct{ Count int }
+		first <- queryer.Query(context.Background(), input, &result)
+	}()
+	require.Eventually(t, func() bool {
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		return len(server.headers) == 1
+	}, time.Second, time.Millisecond)
+
+	// the ones that start in the meantime can give up without waiting for it
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := queryer.Subscribe(ctx, input)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// or share it once it is open
+	second := make(chan error, 1)
+	go func() {
+		var result struct{ Count int }
+		second <- queryer.Query(context.Background(), input, &result)
+	}()
+	close(hold)
+	for _, done := range []chan error{first, second} {
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("operation did not finish")
+		}
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	assert.Len(t, server.headers, 1)
+}
+
+func TestSubscriptionQueryer_handshakeFailure(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	_, err := NewSubscriptionQueryer(server.URL).Subscribe(context.Background(), &QueryInput{Query: "subscription { a }"})
+	assert.Error(t, err)
+}
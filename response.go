@@ -0,0 +1,95 @@
+package graphql
+
+import (
+	"net/http"
+
+	"github.com/go-viper/mapstructure/v2"
+)
+
+// Response can be passed as the receiver of SingleRequestQueryer and MultiOpQueryer to get everything the
+// server answered with instead of just the data:
+//
+//	var data struct{ User struct{ Name string } }
+//	response := &Response{Data: &data}
+//	err := queryer.Query(ctx, input, response)
+//
+// The queryers still return the errors of the response, which are also in Errors. HTTP errors fill in
+// the response too, as far as the body of the failed request allows, but only the HTTP error is returned.
+type Response struct {
+	// Data is the receiver for the data of the response. It's set to the raw data if it's left nil.
+	Data interface{}
+	// Errors are the errors of the response, if any
+	Errors ErrorList
+	// Extensions are the top-level extensions of the response, like tracing or cache hints
+	Extensions map[string]interface{}
+
+	// StatusCode and Header come from the HTTP response. Batched operations share the ones of their batch.
+	StatusCode int
+	Header     http.Header
+}
+
+// setHTTPResponse copies the metadata of the HTTP response
+func (r *Response) setHTTPResponse(resp *http.Response) {
+	if resp == nil {
+		return
+	}
+	r.StatusCode = resp.StatusCode
+	r.Header = resp.Header
+}
+
+// decodeResponse writes the result of an operation to the receiver and returns its errors, if any
+func decodeResponse(queryer *NetworkQueryer, result map[string]interface{}, resp *http.Response, receiver interface{}) error {
+	response, ok := receiver.(*Response)
+	if ok {
+		response.setHTTPResponse(resp)
+		if extensions, ok := result["extensions"].(map[string]interface{}); ok {
+			response.Extensions = extensions
+		}
+
+		// without a receiver for the data we hand it over as is
+		if response.Data == nil {
+			response.Data = result["data"]
+			receiver = nil
+		} else {
+			receiver = response.Data
+		}
+	}
+
+	// assign the result under the data key to the receiver
+	if receiver != nil {
+		decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+			TagName: "json",
+			Result:  receiver,
+		})
+		if err != nil {
+			return err
+		}
+		if err := decoder.Decode(result["data"]); err != nil {
+			return err
+		}
+	}
+
+	// finally extract errors, if any, and return them
+	err := queryer.ExtractErrors(result)
+	if errs, isList := err.(ErrorList); isList && ok {
+		response.Errors = errs
+	}
+	return err
+}
+
+// decodeFailedResponse writes what it can of the response of a failed request to the receiver, if it's a Response,
+// and returns the error of the request. Other receivers are left alone.
+func decodeFailedResponse(queryer *NetworkQueryer, result map[string]interface{}, resp *http.Response, receiver interface{}, err error) error {
+	response, ok := receiver.(*Response)
+	if !ok {
+		return err
+	}
+	if result == nil {
+		response.setHTTPResponse(resp)
+		return err
+	}
+
+	// the body of a failed request usually holds errors and extensions, like the cost of the request
+	_ = decodeResponse(queryer, result, resp, response)
+	return err
+}
@@ -0,0 +1,186 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func responseTestClient(status int, body string) *http.Client {
+	return &http.Client{
+		Transport: roundTripFunc(func(req *http.Request) *http.Response {
+			return &http.Response{
+				StatusCode: status,
+				Body:       io.NopCloser(bytes.NewBufferString(body)),
+				Header: http.Header{
+					"Cache-Control":         []string{"max-age=60"},
+					"X-Ratelimit-Remaining": []string{"10"},
+				},
+			}
+		}),
+	}
+}
+
+func TestResponse_queryers(t *testing.T) {
+	t.Parallel()
+	body := `{
+		"data": {"user": {"name": "John"}},
+		"errors": [{"message": "no friends", "path": ["user", "friends"]}],
+		"extensions": {"cacheControl": {"version": 1, "hints": [{"path": ["user"], "maxAge": 60}]}}
+	}`
+
+	for _, row := range []struct {
+		Message string
+		Queryer HTTPQueryer
+		Body    string
+	}{
+		{"Single Request", NewSingleRequestQueryer("http://localhost/graphql"), body},
+		{"MultiOp", NewMultiOpQueryer("http://localhost/graphql", time.Millisecond, 10), "[" + body + "]"},
+	} {
+		row := row
+		t.Run(row.Message, func(t *testing.T) {
+			t.Parallel()
+			queryer := row.Queryer.WithHTTPClient(responseTestClient(http.StatusOK, row.Body))
+
+			var data struct {
+				User struct {
+					Name string
+				}
+			}
+			response := &Response{Data: &data}
+			err := queryer.Query(context.Background(), &QueryInput{Query: "{ user { name, friends { name } } }"}, response)
+
+			// errors are still returned
+			require.Error(t, err)
+			assert.EqualError(t, err, "no friends")
+			assert.Equal(t, err, response.Errors)
+			require.Len(t, response.Errors, 1)
+			assert.Equal(t, []interface{}{"user", "friends"}, response.Errors[0].(*Error).Path)
+
+			assert.Equal(t, "John", data.User.Name)
+			assert.Equal(t, map[string]interface{}{
+				"cacheControl": map[string]interface{}{
+					"version": float64(1),
+					"hints":   []interface{}{map[string]interface{}{"path": []interface{}{"user"}, "maxAge": float64(60)}},
+				},
+			}, response.Extensions)
+			assert.Equal(t, http.StatusOK, response.StatusCode)
+			assert.Equal(t, "max-age=60", response.Header.Get("Cache-Control"))
+			assert.Equal(t, "10", response.Header.Get("X-Ratelimit-Remaining"))
+		})
+	}
+}
+
+func TestResponse_rawData(t *testing.T) {
+	t.Parallel()
+	queryer := NewSingleRequestQueryer("http://localhost/graphql").
+		WithHTTPClient(responseTestClient(http.StatusOK, `{"data": {"me": {"name": "John"}}}`))
+
+	// without a receiver the data is handed over as is
+	response := &Response{}
+	require.NoError(t, queryer.Query(context.Background(), &QueryInput{Query: "{ me { name } }"}, response))
+	assert.Equal(t, map[string]interface{}{"me": map[string]interface{}{"name": "John"}}, response.Data)
+	assert.Nil(t, response.Errors)
+	assert.Nil(t, response.Extensions)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
+
+func TestResponse_httpErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, row := range []struct {
+		Message string
+		Queryer HTTPQueryer
+	}{
+		{"Single Request", NewSingleRequestQueryer("http://localhost/graphql")},
+		{"MultiOp", NewMultiOpQueryer("http://localhost/graphql", time.Millisecond, 10)},
+	} {
+		row := row
+		t.Run(row.Message, func(t *testing.T) {
+			t.Parallel()
+			queryer := row.Queryer.WithHTTPClient(responseTestClient(http.StatusTooManyRequests, "slow down"))
+
+			// the status and headers of the failed request are there to act on
+			response := &Response{}
+			err := queryer.Query(context.Background(), &QueryInput{Query: "{ me { name } }"}, response)
+			assert.EqualError(t, err, "response was not successful with status code: 429")
+			assert.Equal(t, http.StatusTooManyRequests, response.StatusCode)
+			assert.Equal(t, "10", response.Header.Get("X-Ratelimit-Remaining"))
+		})
+	}
+}
+
+func TestResponse_httpErrorsWithBody(t *testing.T) {
+	t.Parallel()
+	body := `{
+		"errors": [{"message": "too many requests", "extensions": {"code": "RATE_LIMITED"}}],
+		"extensions": {"cost": {"requested": 120, "limit": 100}}
+	}`
+
+	for _, row := range []struct {
+		Message string
+		Queryer HTTPQueryer
+		Body    string
+	}{
+		{"Single Request", NewSingleRequestQueryer("http://localhost/graphql"), body},
+		{"MultiOp", NewMultiOpQueryer("http://localhost/graphql", time.Millisecond, 10), "[" + body + "]"},
+		{"MultiOp with a single error", NewMultiOpQueryer("http://localhost/graphql", time.Millisecond, 10), body},
+	} {
+		row := row
+		t.Run(row.Message, func(t *testing.T) {
+			t.Parallel()
+			queryer := row.Queryer.WithHTTPClient(responseTestClient(http.StatusTooManyRequests, row.Body))
+
+			// the errors and extensions in the body are kept along with the status
+			response := &Response{}
+			err := queryer.Query(context.Background(), &QueryInput{Query: "{ me { name } }"}, response)
+			assert.EqualError(t, err, "response was not successful with status code: 429")
+
+			assert.Equal(t, http.StatusTooManyRequests, response.StatusCode)
+			require.Len(t, response.Errors, 1)
+			assert.Equal(t, "RATE_LIMITED", response.Errors[0].(*Error).Extensions["code"])
+			assert.Equal(t, map[string]interface{}{
+				"cost": map[string]interface{}{"requested": float64(120), "limit": float64(100)},
+			}, response.Extensions)
+		})
+	}
+}
+
+func TestResponse_httpErrorsWithPlainReceiver(t *testing.T) {
+	t.Parallel()
+	body := `{"data": {"me": {"name": "John"}}, "errors": [{"message": "boom"}]}`
+
+	for _, row := range []struct {
+		Message string
+		Queryer HTTPQueryer
+		Body    string
+	}{
+		{"Single Request", NewSingleRequestQueryer("http://localhost/graphql"), body},
+		{"MultiOp", NewMultiOpQueryer("http://localhost/graphql", time.Millisecond, 10), "[" + body + "]"},
+	} {
+		row := row
+		t.Run(row.Message, func(t *testing.T) {
+			t.Parallel()
+			queryer := row.Queryer.WithHTTPClient(responseTestClient(http.StatusInternalServerError, row.Body))
+
+			// other receivers only get the HTTP error, like before
+			var data struct {
+				Me struct {
+					Name string
+				}
+			}
+			err := queryer.Query(context.Background(), &QueryInput{Query: "{ me { name } }"}, &data)
+			assert.EqualError(t, err, "response was not successful with status code: 500")
+			var errs ErrorList
+			assert.False(t, errors.As(err, &errs))
+			assert.Empty(t, data.Me.Name)
+		})
+	}
+}
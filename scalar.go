@@ -0,0 +1,552 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// Scalar converts the values of a custom scalar between the representation used by Go code and the one
+// sent over the wire
+type Scalar interface {
+	// Serialize validates the value of a variable and returns the value to encode as JSON
+	Serialize(value interface{}) (interface{}, error)
+	// Parse validates a value found in a response and returns the value to hand to the receiver.
+	// Numbers are passed as json.Number when the response was decoded without losing precision.
+	Parse(value interface{}) (interface{}, error)
+}
+
+// ScalarFuncs implements Scalar with a pair of functions. A nil function leaves the values untouched.
+type ScalarFuncs struct {
+	SerializeFunc func(value interface{}) (interface{}, error)
+	ParseFunc     func(value interface{}) (interface{}, error)
+}
+
+// Serialize calls SerializeFunc
+func (s *ScalarFuncs) Serialize(value interface{}) (interface{}, error) {
+	if s.SerializeFunc == nil {
+		return value, nil
+	}
+	return s.SerializeFunc(value)
+}
+
+// Parse calls ParseFunc
+func (s *ScalarFuncs) Parse(value interface{}) (interface{}, error) {
+	if s.ParseFunc == nil {
+		return value, nil
+	}
+	return s.ParseFunc(value)
+}
+
+var (
+	// DateTimeScalar is an RFC 3339 timestamp. It serializes a time.Time or an RFC 3339 string and parses
+	// into a time.Time.
+	DateTimeScalar Scalar = dateTimeScalar{}
+	// JSONScalar is an arbitrary JSON value. It serializes anything encoding/json can marshal and parses
+	// into the same values json.Unmarshal produces for an interface{}.
+	JSONScalar Scalar = jsonScalar{}
+	// UUIDScalar is a UUID in its canonical textual form. It serializes a string or a fmt.Stringer, such as
+	// the UUID types of the common uuid packages, and parses into a string.
+	UUIDScalar Scalar = uuidScalar{}
+	// BigIntScalar is an integer of arbitrary size. It serializes a *big.Int, any Go integer or a string of
+	// digits into a JSON number, and parses into a *big.Int without losing precision.
+	BigIntScalar Scalar = bigIntScalar{}
+)
+
+// ScalarRegistry holds the Scalar implementations of the custom scalars of an API, by type name
+type ScalarRegistry struct {
+	mu      sync.RWMutex
+	scalars map[string]Scalar
+}
+
+// NewScalarRegistry returns a ScalarRegistry with the built-in DateTime, JSON, UUID and BigInt scalars
+func NewScalarRegistry() *ScalarRegistry {
+	registry := &ScalarRegistry{scalars: map[string]Scalar{}}
+	registry.Register("DateTime", DateTimeScalar)
+	registry.Register("JSON", JSONScalar)
+	registry.Register("UUID", UUIDScalar)
+	registry.Register("BigInt", BigIntScalar)
+	return registry
+}
+
+// Register sets the implementation of the scalar with the given name, replacing any previous one.
+// A built-in implementation can be registered under another name, e.g. Register("Long", BigIntScalar).
+func (r *ScalarRegistry) Register(name string, scalar Scalar) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.scalars[name] = scalar
+}
+
+// Lookup returns the implementation of the scalar with the given name
+func (r *ScalarRegistry) Lookup(name string) (Scalar, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	scalar, ok := r.scalars[name]
+	return scalar, ok
+}
+
+// ScalarError describes a value that a Scalar could not serialize or parse
+type ScalarError struct {
+	// Path locates the value, e.g. $filter.after for a variable or users.1.createdAt for a response
+	Path   string
+	Scalar string
+	Err    error
+}
+
+func (e *ScalarError) Error() string {
+	return fmt.Sprintf("%s: invalid %s: %s", e.Path, e.Scalar, e.Err)
+}
+
+func (e *ScalarError) Unwrap() error {
+	return e.Err
+}
+
+// SerializeVariables returns a copy of the input whose variables have been serialized by the scalars registered
+// for the types the operation declares them with. The schema is used to find the scalars inside of input objects
+// given as maps and can be nil. A value that a scalar rejects is returned as a *ScalarError.
+func (r *ScalarRegistry) SerializeVariables(schema *ast.Schema, input *QueryInput) (*QueryInput, error) {
+	if len(input.Variables) == 0 {
+		return input, nil
+	}
+	document, operation, err := inputOperation(input)
+	if err != nil {
+		return nil, err
+	}
+
+	variables := make(map[string]interface{}, len(input.Variables))
+	for name, value := range input.Variables {
+		variables[name] = value
+	}
+	for _, definition := range operation.VariableDefinitions {
+		value, ok := variables[definition.Variable]
+		if !ok {
+			continue
+		}
+		serialized, err := r.serializeValue(schema, "$"+definition.Variable, definition.Type, value)
+		if err != nil {
+			return nil, err
+		}
+		variables[definition.Variable] = serialized
+	}
+
+	serializedInput := *input
+	serializedInput.QueryDocument = document
+	serializedInput.Variables = variables
+	return &serializedInput, nil
+}
+
+func (r *ScalarRegistry) serializeValue(schema *ast.Schema, path string, typ *ast.Type, value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	if typ.Elem != nil {
+		list := reflect.ValueOf(value)
+		if list.Kind() != reflect.Slice && list.Kind() != reflect.Array {
+			// a single value is accepted where a list is expected
+			return r.serializeValue(schema, path, typ.Elem, value)
+		}
+		if list.Kind() == reflect.Slice && list.IsNil() {
+			return nil, nil
+		}
+
+		serialized := make([]interface{}, list.Len())
+		for i := range serialized {
+			item, err := r.serializeValue(schema, fmt.Sprintf("%s.%d", path, i), typ.Elem, list.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			serialized[i] = item
+		}
+		return serialized, nil
+	}
+
+	if scalar, ok := r.Lookup(typ.NamedType); ok {
+		serialized, err := scalar.Serialize(value)
+		if err != nil {
+			return nil, &ScalarError{Path: path, Scalar: typ.NamedType, Err: err}
+		}
+		return serialized, nil
+	}
+
+	// look for scalars inside of input objects
+	if schema == nil {
+		return value, nil
+	}
+	definition := schema.Types[typ.NamedType]
+	object, ok := value.(map[string]interface{})
+	if definition == nil || definition.Kind != ast.InputObject || !ok {
+		return value, nil
+	}
+
+	serialized := make(map[string]interface{}, len(object))
+	for name, fieldValue := range object {
+		serialized[name] = fieldValue
+	}
+	for _, field := range definition.Fields {
+		fieldValue, ok := object[field.Name]
+		if !ok {
+			continue
+		}
+		serializedField, err := r.serializeValue(schema, path+"."+field.Name, field.Type, fieldValue)
+		if err != nil {
+			return nil, err
+		}
+		serialized[field.Name] = serializedField
+	}
+	return serialized, nil
+}
+
+// ParseResponse replaces the values of the registered scalars in the data of a response with the ones returned
+// by their Parse method, using the schema to find the types of the fields the operation of the input selects.
+// Numbers decoded as json.Number, as the queryers do when they are given a ScalarRegistry, are passed to the
+// scalars untouched and turned into float64 everywhere else. A value that a scalar rejects is returned
+// as a *ScalarError.
+func (r *ScalarRegistry) ParseResponse(schema *ast.Schema, input *QueryInput, data map[string]interface{}) error {
+	document, operation, err := inputOperation(input)
+	if err != nil {
+		return err
+	}
+
+	var root *ast.Definition
+	switch operation.Operation {
+	case ast.Mutation:
+		root = schema.Mutation
+	case ast.Subscription:
+		root = schema.Subscription
+	default:
+		root = schema.Query
+	}
+	if root == nil {
+		return fmt.Errorf("schema does not have a %s type", operation.Operation)
+	}
+
+	p := &scalarResponseParser{registry: r, schema: schema, document: document}
+	return p.parseObject("", root, operation.SelectionSet, data)
+}
+
+// inputOperation returns the document of the input, parsing it if needed, along with the operation to execute
+func inputOperation(input *QueryInput) (*ast.QueryDocument, *ast.OperationDefinition, error) {
+	document := input.QueryDocument
+	if document == nil {
+		parsed, err := parser.ParseQuery(&ast.Source{Input: input.Query})
+		if err != nil {
+			return nil, nil, err
+		}
+		document = parsed
+	}
+
+	operation := document.Operations.ForName(input.OperationName)
+	if operation == nil {
+		return nil, nil, fmt.Errorf("could not find operation %q", input.OperationName)
+	}
+	return document, operation, nil
+}
+
+// scalarResponseParser walks the data of a response along the operation that produced it
+type scalarResponseParser struct {
+	registry *ScalarRegistry
+	schema   *ast.Schema
+	document *ast.QueryDocument
+}
+
+// scalarField is a field of the response along with every selection set it was selected with
+type scalarField struct {
+	definition   *ast.FieldDefinition
+	selectionSet ast.SelectionSet
+}
+
+func (p *scalarResponseParser) parseObject(path string, parent *ast.Definition, selectionSet ast.SelectionSet, object map[string]interface{}) error {
+	keys := []string{}
+	fields := map[string]*scalarField{}
+	p.collectFields(parent, selectionSet, &keys, fields, map[string]bool{})
+
+	parsed := map[string]bool{}
+	for _, key := range keys {
+		value, ok := object[key]
+		if !ok {
+			continue
+		}
+		fieldPath := key
+		if path != "" {
+			fieldPath = path + "." + key
+		}
+
+		field := fields[key]
+		value, err := p.parseValue(fieldPath, field.definition.Type, field.selectionSet, value)
+		if err != nil {
+			return err
+		}
+		object[key] = value
+		parsed[key] = true
+	}
+
+	// the values the operation doesn't describe, like __typename, get the usual numbers
+	for key, value := range object {
+		if !parsed[key] {
+			object[key] = normalizeJSONNumbers(value)
+		}
+	}
+	return nil
+}
+
+// collectFields groups the fields selected on an object by response key, merging the selection sets
+// of the fields that are selected more than once
+func (p *scalarResponseParser) collectFields(parent *ast.Definition, selectionSet ast.SelectionSet, keys *[]string, fields map[string]*scalarField, visited map[string]bool) {
+	for _, selection := range selectionSet {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			definition := parent.Fields.ForName(selection.Name)
+			if definition == nil {
+				continue
+			}
+			key := selection.Alias
+			if key == "" {
+				key = selection.Name
+			}
+
+			if field, ok := fields[key]; ok {
+				field.selectionSet = append(append(ast.SelectionSet{}, field.selectionSet...), selection.SelectionSet...)
+				continue
+			}
+			fields[key] = &scalarField{definition: definition, selectionSet: selection.SelectionSet}
+			*keys = append(*keys, key)
+
+		case *ast.InlineFragment:
+			p.collectFields(p.fragmentType(parent, selection.TypeCondition), selection.SelectionSet, keys, fields, visited)
+
+		case *ast.FragmentSpread:
+			fragment := p.document.Fragments.ForName(selection.Name)
+			if fragment == nil || visited[selection.Name] {
+				continue
+			}
+			visited[selection.Name] = true
+			p.collectFields(p.fragmentType(parent, fragment.TypeCondition), fragment.SelectionSet, keys, fields, visited)
+		}
+	}
+}
+
+// fragmentType returns the type the fields of a fragment are selected on
+func (p *scalarResponseParser) fragmentType(parent *ast.Definition, typeCondition string) *ast.Definition {
+	if definition, ok := p.schema.Types[typeCondition]; ok {
+		return definition
+	}
+	return parent
+}
+
+func (p *scalarResponseParser) parseValue(path string, typ *ast.Type, selectionSet ast.SelectionSet, value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	if typ.Elem != nil {
+		list, ok := value.([]interface{})
+		if !ok {
+			return normalizeJSONNumbers(value), nil
+		}
+		for i, item := range list {
+			parsed, err := p.parseValue(fmt.Sprintf("%s.%d", path, i), typ.Elem, selectionSet, item)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = parsed
+		}
+		return list, nil
+	}
+
+	if scalar, ok := p.registry.Lookup(typ.NamedType); ok {
+		parsed, err := scalar.Parse(value)
+		if err != nil {
+			return nil, &ScalarError{Path: path, Scalar: typ.NamedType, Err: err}
+		}
+		return parsed, nil
+	}
+
+	definition := p.schema.Types[typ.NamedType]
+	if object, ok := value.(map[string]interface{}); ok && definition != nil && len(selectionSet) > 0 {
+		return object, p.parseObject(path, definition, selectionSet, object)
+	}
+	return normalizeJSONNumbers(value), nil
+}
+
+// unmarshalResponse decodes the body of a response, keeping the text of numbers if scalars have to parse them
+func unmarshalResponse(body []byte, result interface{}, scalars *ScalarRegistry) error {
+	if scalars == nil {
+		return json.Unmarshal(body, result)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	return decoder.Decode(result)
+}
+
+// parseResponseScalars parses the scalars in the data of a response decoded by unmarshalResponse and turns every
+// other json.Number back into a float64. Without a schema, only the numbers are converted.
+func parseResponseScalars(scalars *ScalarRegistry, schema *ast.Schema, input *QueryInput, result map[string]interface{}) error {
+	parsedData := false
+	if data, ok := result["data"].(map[string]interface{}); ok && schema != nil {
+		if err := scalars.ParseResponse(schema, input, data); err != nil {
+			return err
+		}
+		parsedData = true
+	}
+
+	for key, value := range result {
+		if key != "data" || !parsedData {
+			result[key] = normalizeJSONNumbers(value)
+		}
+	}
+	return nil
+}
+
+// normalizeJSONNumbers replaces the json.Numbers in a decoded value with the float64 json.Unmarshal would use
+func normalizeJSONNumbers(value interface{}) interface{} {
+	switch value := value.(type) {
+	case json.Number:
+		// like json.Unmarshal, numbers beyond the range of a float64 can't be represented
+		number, _ := strconv.ParseFloat(string(value), 64)
+		return number
+	case map[string]interface{}:
+		for key, item := range value {
+			value[key] = normalizeJSONNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = normalizeJSONNumbers(item)
+		}
+	}
+	return value
+}
+
+type dateTimeScalar struct{}
+
+func (dateTimeScalar) Serialize(value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case time.Time:
+		return value.Format(time.RFC3339Nano), nil
+	case *time.Time:
+		if value == nil {
+			return nil, nil
+		}
+		return value.Format(time.RFC3339Nano), nil
+	case string:
+		if _, err := time.Parse(time.RFC3339Nano, value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+	return nil, fmt.Errorf("expected a time.Time or an RFC 3339 string, got %T", value)
+}
+
+func (dateTimeScalar) Parse(value interface{}) (interface{}, error) {
+	str, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected a string, got %T", value)
+	}
+	return time.Parse(time.RFC3339Nano, str)
+}
+
+type jsonScalar struct{}
+
+func (jsonScalar) Serialize(value interface{}) (interface{}, error) {
+	if _, err := json.Marshal(value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (jsonScalar) Parse(value interface{}) (interface{}, error) {
+	return normalizeJSONNumbers(value), nil
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+type uuidScalar struct{}
+
+func (uuidScalar) Serialize(value interface{}) (interface{}, error) {
+	var str string
+	switch value := value.(type) {
+	case string:
+		str = value
+	case fmt.Stringer:
+		str = value.String()
+	default:
+		return nil, fmt.Errorf("expected a string or a fmt.Stringer, got %T", value)
+	}
+	return uuidScalar{}.Parse(str)
+}
+
+func (uuidScalar) Parse(value interface{}) (interface{}, error) {
+	str, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected a string, got %T", value)
+	}
+	if !uuidPattern.MatchString(str) {
+		return nil, fmt.Errorf("%q is not a UUID", str)
+	}
+	return str, nil
+}
+
+type bigIntScalar struct{}
+
+func (bigIntScalar) Serialize(value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case *big.Int:
+		if value == nil {
+			return nil, nil
+		}
+		return json.Number(value.String()), nil
+	case big.Int:
+		return json.Number(value.String()), nil
+	case string, json.Number:
+		parsed, err := bigIntScalar{}.Parse(value)
+		if err != nil {
+			return nil, err
+		}
+		return json.Number(parsed.(*big.Int).String()), nil
+	}
+
+	// any other kind of integer
+	number := reflect.ValueOf(value)
+	switch number.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return json.Number(strconv.FormatInt(number.Int(), 10)), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return json.Number(strconv.FormatUint(number.Uint(), 10)), nil
+	}
+	return nil, fmt.Errorf("expected an integer, got %T", value)
+}
+
+func (bigIntScalar) Parse(value interface{}) (interface{}, error) {
+	var str string
+	switch value := value.(type) {
+	case json.Number:
+		str = string(value)
+	case string:
+		str = value
+	case float64:
+		// the precision of responses decoded without json.Number is already lost
+		if value != math.Trunc(value) || math.IsInf(value, 0) {
+			return nil, fmt.Errorf("%v is not an integer", value)
+		}
+		number, _ := big.NewFloat(value).Int(nil)
+		return number, nil
+	default:
+		return nil, fmt.Errorf("expected a number or a string, got %T", value)
+	}
+
+	number, ok := new(big.Int).SetString(str, 10)
+	if !ok {
+		return nil, fmt.Errorf("%q is not an integer", str)
+	}
+	return number, nil
+}
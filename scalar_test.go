@@ -0,0 +1,401 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const scalarTestSchema = `
+	scalar DateTime
+	scalar JSON
+	scalar UUID
+	scalar BigInt
+	scalar Long
+
+	type Query {
+		user(id: UUID!): User
+		users(filter: UserFilter): [User!]!
+		node(id: UUID!): Node
+	}
+
+	type Mutation {
+		touch(ids: [UUID!]!, at: DateTime): [User]
+	}
+
+	interface Node {
+		id: UUID!
+	}
+
+	type User implements Node {
+		id: UUID!
+		name: String
+		age: Int
+		createdAt: DateTime
+		balance: BigInt
+		visits: Long
+		settings: JSON
+		friends: [User!]
+	}
+
+	input UserFilter {
+		createdAfter: DateTime
+		minBalance: BigInt
+		name: String
+	}
+`
+
+func TestBuiltinScalars(t *testing.T) {
+	t.Parallel()
+	when := time.Date(2024, 3, 1, 12, 30, 0, 500, time.UTC)
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	uuid := "3f1c7a52-9d4e-4b8a-8f2e-0c6d5b4a3e21"
+
+	for _, row := range []struct {
+		Message   string
+		Scalar    Scalar
+		Serialize interface{}
+		Expected  interface{}
+		Error     bool
+	}{
+		{"DateTime time", DateTimeScalar, when, "2024-03-01T12:30:00.0000005Z", false},
+		{"DateTime pointer", DateTimeScalar, &when, "2024-03-01T12:30:00.0000005Z", false},
+		{"DateTime string", DateTimeScalar, "2024-03-01T12:30:00+01:00", "2024-03-01T12:30:00+01:00", false},
+		{"DateTime invalid string", DateTimeScalar, "yesterday", nil, true},
+		{"DateTime number", DateTimeScalar, 1709296200, nil, true},
+		{"UUID string", UUIDScalar, uuid, uuid, false},
+		{"UUID stringer", UUIDScalar, scalarTestStringer(uuid), uuid, false},
+		{"UUID invalid", UUIDScalar, "not-a-uuid", nil, true},
+		{"BigInt big", BigIntScalar, huge, json.Number("123456789012345678901234567890"), false},
+		{"BigInt int", BigIntScalar, int64(-42), json.Number("-42"), false},
+		{"BigInt uint", BigIntScalar, uint8(42), json.Number("42"), false},
+		{"BigInt string", BigIntScalar, "123456789012345678901234567890", json.Number("123456789012345678901234567890"), false},
+		{"BigInt invalid string", BigIntScalar, "12.5", nil, true},
+		{"BigInt float", BigIntScalar, 12.5, nil, true},
+		{"JSON object", JSONScalar, map[string]interface{}{"a": []int{1}}, map[string]interface{}{"a": []int{1}}, false},
+		{"JSON unmarshalable", JSONScalar, make(chan int), nil, true},
+	} {
+		row := row
+		t.Run(row.Message, func(t *testing.T) {
+			t.Parallel()
+			serialized, err := row.Scalar.Serialize(row.Serialize)
+			if row.Error {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, row.Expected, serialized)
+		})
+	}
+
+	t.Run("parse", func(t *testing.T) {
+		t.Parallel()
+		parsed, err := DateTimeScalar.Parse("2024-03-01T12:30:00.0000005Z")
+		require.NoError(t, err)
+		assert.True(t, when.Equal(parsed.(time.Time)))
+		_, err = DateTimeScalar.Parse(float64(1))
+		assert.Error(t, err)
+
+		parsed, err = BigIntScalar.Parse(json.Number("123456789012345678901234567890"))
+		require.NoError(t, err)
+		assert.Equal(t, huge, parsed)
+		parsed, err = BigIntScalar.Parse(float64(1e3))
+		require.NoError(t, err)
+		assert.Equal(t, big.NewInt(1000), parsed)
+		_, err = BigIntScalar.Parse(true)
+		assert.Error(t, err)
+
+		parsed, err = UUIDScalar.Parse(uuid)
+		require.NoError(t, err)
+		assert.Equal(t, uuid, parsed)
+		_, err = UUIDScalar.Parse("3f1c7a52")
+		assert.Error(t, err)
+
+		parsed, err = JSONScalar.Parse(map[string]interface{}{"a": json.Number("1.5")})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"a": 1.5}, parsed)
+	})
+}
+
+type scalarTestStringer string
+
+func (s scalarTestStringer) String() string {
+	return string(s)
+}
+
+func TestScalarRegistry_Register(t *testing.T) {
+	t.Parallel()
+	registry := NewScalarRegistry()
+
+	for _, name := range []string{"DateTime", "JSON", "UUID", "BigInt"} {
+		_, ok := registry.Lookup(name)
+		assert.True(t, ok, name)
+	}
+	_, ok := registry.Lookup("Long")
+	assert.False(t, ok)
+
+	registry.Register("Long", BigIntScalar)
+	scalar, ok := registry.Lookup("Long")
+	assert.True(t, ok)
+	assert.Equal(t, BigIntScalar, scalar)
+
+	// functions can be registered too
+	registry.Register("Upper", &ScalarFuncs{
+		ParseFunc: func(value interface{}) (interface{}, error) {
+			return fmt.Sprintf("%v!", value), nil
+		},
+	})
+	scalar, _ = registry.Lookup("Upper")
+	serialized, err := scalar.Serialize("a")
+	require.NoError(t, err)
+	assert.Equal(t, "a", serialized)
+	parsed, err := scalar.Parse("a")
+	require.NoError(t, err)
+	assert.Equal(t, "a!", parsed)
+}
+
+func TestScalarRegistry_SerializeVariables(t *testing.T) {
+	t.Parallel()
+	schema, err := LoadSchema(scalarTestSchema)
+	require.NoError(t, err)
+	registry := NewScalarRegistry()
+	when := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+
+	input := &QueryInput{
+		Query: `
+			query Users($filter: UserFilter, $name: String) { users(filter: $filter) { name } }
+			mutation Touch($ids: [UUID!]!, $at: DateTime) { touch(ids: $ids, at: $at) { name } }
+		`,
+		OperationName: "Touch",
+		Variables: map[string]interface{}{
+			"ids":   []string{"3f1c7a52-9d4e-4b8a-8f2e-0c6d5b4a3e21"},
+			"at":    when,
+			"extra": when,
+		},
+	}
+	serialized, err := registry.SerializeVariables(schema, input)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"ids":   []interface{}{"3f1c7a52-9d4e-4b8a-8f2e-0c6d5b4a3e21"},
+		"at":    "2024-03-01T12:30:00Z",
+		"extra": when,
+	}, serialized.Variables)
+	// the original input is left alone
+	assert.Equal(t, when, input.Variables["at"])
+	assert.NotNil(t, serialized.QueryDocument)
+
+	// input objects are serialized field by field
+	input = &QueryInput{
+		Query:         input.Query,
+		OperationName: "Users",
+		Variables: map[string]interface{}{
+			"filter": map[string]interface{}{"createdAfter": when, "minBalance": big.NewInt(10), "name": "a"},
+			"name":   "b",
+		},
+	}
+	serialized, err = registry.SerializeVariables(schema, input)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"filter": map[string]interface{}{"createdAfter": "2024-03-01T12:30:00Z", "minBalance": json.Number("10"), "name": "a"},
+		"name":   "b",
+	}, serialized.Variables)
+
+	// which needs a schema
+	serialized, err = registry.SerializeVariables(nil, input)
+	require.NoError(t, err)
+	assert.Equal(t, input.Variables, serialized.Variables)
+
+	// invalid values are reported with their path
+	input.Variables = map[string]interface{}{
+		"filter": map[string]interface{}{"createdAfter": "yesterday"},
+	}
+	_, err = registry.SerializeVariables(schema, input)
+	var scalarErr *ScalarError
+	require.True(t, errors.As(err, &scalarErr))
+	assert.Equal(t, "$filter.createdAfter", scalarErr.Path)
+	assert.Equal(t, "DateTime", scalarErr.Scalar)
+
+	input.OperationName = "Missing"
+	_, err = registry.SerializeVariables(schema, input)
+	assert.Error(t, err)
+}
+
+func TestScalarRegistry_ParseResponse(t *testing.T) {
+	t.Parallel()
+	schema, err := LoadSchema(scalarTestSchema)
+	require.NoError(t, err)
+	registry := NewScalarRegistry()
+	registry.Register("Long", BigIntScalar)
+
+	input := &QueryInput{
+		Query: `
+			query {
+				users {
+					age
+					joined: createdAt
+					... on User { balance }
+					...UserFields
+					friends { createdAt }
+				}
+				node(id: "3f1c7a52-9d4e-4b8a-8f2e-0c6d5b4a3e21") {
+					__typename
+					... on User { visits }
+				}
+			}
+
+			fragment UserFields on Node {
+				... on User { settings balance }
+			}
+		`,
+	}
+
+	data := map[string]interface{}{}
+	decoder := json.NewDecoder(strings.NewReader(`{
+		"users": [
+			{
+				"age": 30,
+				"joined": "2024-03-01T12:30:00Z",
+				"balance": 123456789012345678901234567890,
+				"settings": {"theme": "dark", "size": 12},
+				"friends": [{"createdAt": null}, {"createdAt": "2024-01-01T00:00:00Z"}]
+			}
+		],
+		"node": {"__typename": "User", "visits": 9007199254740993}
+	}`))
+	decoder.UseNumber()
+	require.NoError(t, decoder.Decode(&data))
+
+	require.NoError(t, registry.ParseResponse(schema, input, data))
+
+	balance, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	assert.Equal(t, map[string]interface{}{
+		"users": []interface{}{
+			map[string]interface{}{
+				"age":      float64(30),
+				"joined":   time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC),
+				"balance":  balance,
+				"settings": map[string]interface{}{"theme": "dark", "size": float64(12)},
+				"friends": []interface{}{
+					map[string]interface{}{"createdAt": nil},
+					map[string]interface{}{"createdAt": time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+				},
+			},
+		},
+		"node": map[string]interface{}{"__typename": "User", "visits": big.NewInt(9007199254740993)},
+	}, data)
+
+	// invalid values are reported with their path
+	err = registry.ParseResponse(schema, input, map[string]interface{}{
+		"users": []interface{}{
+			map[string]interface{}{"friends": []interface{}{map[string]interface{}{"createdAt": "yesterday"}}},
+		},
+	})
+	var scalarErr *ScalarError
+	require.True(t, errors.As(err, &scalarErr))
+	assert.Equal(t, "users.0.friends.0.createdAt", scalarErr.Path)
+}
+
+func TestSingleRequestQueryer_WithScalars(t *testing.T) {
+	t.Parallel()
+	schema, err := LoadSchema(scalarTestSchema)
+	require.NoError(t, err)
+
+	var received map[string]interface{}
+	queryer := NewSingleRequestQueryer("someURL").WithScalars(schema, NewScalarRegistry()).(*SingleRequestQueryer)
+	queryer.WithHTTPClient(&http.Client{
+		Transport: roundTripFunc(func(req *http.Request) *http.Response {
+			decoder := json.NewDecoder(req.Body)
+			decoder.UseNumber()
+			require.NoError(t, decoder.Decode(&received))
+
+			w := httptest.NewRecorder()
+			fmt.Fprint(w, `{
+				"data": {"users": [{"name": "a", "createdAt": "2024-03-01T12:30:00Z", "balance": 123456789012345678901234567890}]},
+				"errors": [{"message": "partial", "path": ["users", 0, "age"]}]
+			}`)
+			return w.Result()
+		}),
+	})
+
+	minBalance, _ := new(big.Int).SetString("98765432109876543210", 10)
+	var result struct {
+		Users []struct {
+			Name      string
+			CreatedAt time.Time
+			Balance   *big.Int
+		}
+	}
+	err = queryer.Query(context.Background(), &QueryInput{
+		Query:     `query($filter: UserFilter) { users(filter: $filter) { name createdAt balance } }`,
+		Variables: map[string]interface{}{"filter": map[string]interface{}{"minBalance": minBalance}},
+	}, &result)
+
+	// the error paths keep their usual numbers
+	require.Error(t, err)
+	var errList ErrorList
+	require.True(t, errors.As(err, &errList))
+	assert.Equal(t, []interface{}{"users", float64(0), "age"}, errList[0].(*Error).Path)
+
+	// big numbers go out and come back without losing precision
+	assert.Equal(t, map[string]interface{}{
+		"filter": map[string]interface{}{"minBalance": json.Number("98765432109876543210")},
+	}, received["variables"])
+	require.Len(t, result.Users, 1)
+	assert.Equal(t, "a", result.Users[0].Name)
+	assert.Equal(t, time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC), result.Users[0].CreatedAt)
+	assert.Equal(t, "123456789012345678901234567890", result.Users[0].Balance.String())
+
+	// invalid variables are never sent
+	received = nil
+	err = queryer.Query(context.Background(), &QueryInput{
+		Query:     `query($id: UUID!) { user(id: $id) { name } }`,
+		Variables: map[string]interface{}{"id": "nope"},
+	}, &result)
+	assert.Error(t, err)
+	assert.Nil(t, received)
+}
+
+func TestMultiOpQueryer_WithScalars(t *testing.T) {
+	t.Parallel()
+	schema, err := LoadSchema(scalarTestSchema)
+	require.NoError(t, err)
+
+	queryer := NewMultiOpQueryer("someURL", 1*time.Millisecond, 10).WithScalars(schema, NewScalarRegistry()).(*MultiOpQueryer)
+	queryer.WithHTTPClient(&http.Client{
+		Transport: roundTripFunc(func(req *http.Request) *http.Response {
+			var batch []map[string]interface{}
+			require.NoError(t, json.NewDecoder(req.Body).Decode(&batch))
+			require.Len(t, batch, 1)
+			assert.Equal(t, map[string]interface{}{"at": "2024-03-01T12:30:00Z", "ids": []interface{}{}}, batch[0]["variables"])
+
+			w := httptest.NewRecorder()
+			fmt.Fprint(w, `[{"data": {"touch": [{"age": 3, "createdAt": "2024-03-01T12:30:00Z"}]}}]`)
+			return w.Result()
+		}),
+	})
+
+	var result map[string]interface{}
+	err = queryer.Query(context.Background(), &QueryInput{
+		Query: `mutation($ids: [UUID!]!, $at: DateTime) { touch(ids: $ids, at: $at) { age createdAt } }`,
+		Variables: map[string]interface{}{
+			"ids": []string{},
+			"at":  time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC),
+		},
+	}, &result)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"touch": []interface{}{
+			map[string]interface{}{"age": float64(3), "createdAt": time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)},
+		},
+	}, result)
+}
@@ -0,0 +1,459 @@
+package graphql
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// ChangeSeverity describes how a schema change affects existing clients
+type ChangeSeverity string
+
+const (
+	// ChangeBreaking changes cause existing queries to fail
+	ChangeBreaking ChangeSeverity = "BREAKING"
+	// ChangeDangerous changes keep existing queries valid but can change how they behave
+	ChangeDangerous ChangeSeverity = "DANGEROUS"
+	// ChangeSafe changes do not affect existing queries
+	ChangeSafe ChangeSeverity = "SAFE"
+)
+
+// ChangeType identifies what changed between two schemas
+type ChangeType string
+
+const (
+	ChangeTypeAdded                      ChangeType = "TYPE_ADDED"
+	ChangeTypeRemoved                    ChangeType = "TYPE_REMOVED"
+	ChangeTypeKindChanged                ChangeType = "TYPE_KIND_CHANGED"
+	ChangeTypeDescriptionChanged         ChangeType = "TYPE_DESCRIPTION_CHANGED"
+	ChangeRootTypeChanged                ChangeType = "ROOT_TYPE_CHANGED"
+	ChangeFieldAdded                     ChangeType = "FIELD_ADDED"
+	ChangeFieldRemoved                   ChangeType = "FIELD_REMOVED"
+	ChangeFieldTypeChanged               ChangeType = "FIELD_TYPE_CHANGED"
+	ChangeFieldDescriptionChanged        ChangeType = "FIELD_DESCRIPTION_CHANGED"
+	ChangeFieldDeprecationChanged        ChangeType = "FIELD_DEPRECATION_CHANGED"
+	ChangeFieldDefaultValueChanged       ChangeType = "FIELD_DEFAULT_VALUE_CHANGED"
+	ChangeArgumentAdded                  ChangeType = "ARGUMENT_ADDED"
+	ChangeArgumentRemoved                ChangeType = "ARGUMENT_REMOVED"
+	ChangeArgumentTypeChanged            ChangeType = "ARGUMENT_TYPE_CHANGED"
+	ChangeArgumentDefaultValueChanged    ChangeType = "ARGUMENT_DEFAULT_VALUE_CHANGED"
+	ChangeEnumValueAdded                 ChangeType = "ENUM_VALUE_ADDED"
+	ChangeEnumValueRemoved               ChangeType = "ENUM_VALUE_REMOVED"
+	ChangeEnumValueDeprecationChanged    ChangeType = "ENUM_VALUE_DEPRECATION_CHANGED"
+	ChangeUnionMemberAdded               ChangeType = "UNION_MEMBER_ADDED"
+	ChangeUnionMemberRemoved             ChangeType = "UNION_MEMBER_REMOVED"
+	ChangeInterfaceImplementationAdded   ChangeType = "INTERFACE_IMPLEMENTATION_ADDED"
+	ChangeInterfaceImplementationRemoved ChangeType = "INTERFACE_IMPLEMENTATION_REMOVED"
+	ChangeDirectiveAdded                 ChangeType = "DIRECTIVE_ADDED"
+	ChangeDirectiveRemoved               ChangeType = "DIRECTIVE_REMOVED"
+	ChangeDirectiveLocationAdded         ChangeType = "DIRECTIVE_LOCATION_ADDED"
+	ChangeDirectiveLocationRemoved       ChangeType = "DIRECTIVE_LOCATION_REMOVED"
+	ChangeDirectiveRepeatableChanged     ChangeType = "DIRECTIVE_REPEATABLE_CHANGED"
+)
+
+// SchemaChange is a single difference between two schemas
+type SchemaChange struct {
+	Type     ChangeType
+	Severity ChangeSeverity
+	// Coordinate is the schema coordinate of the changed element, e.g. User.name(first:) or @include(if:)
+	Coordinate string
+	Message    string
+}
+
+func (c *SchemaChange) String() string {
+	return fmt.Sprintf("%s: %s", c.Severity, c.Message)
+}
+
+// SchemaDiff is the list of changes between two schemas
+type SchemaDiff []*SchemaChange
+
+// Filter returns the changes with the given severity
+func (d SchemaDiff) Filter(severity ChangeSeverity) SchemaDiff {
+	result := SchemaDiff{}
+	for _, change := range d {
+		if change.Severity == severity {
+			result = append(result, change)
+		}
+	}
+	return result
+}
+
+// HasBreakingChanges returns true if any of the changes are breaking
+func (d SchemaDiff) HasBreakingChanges() bool {
+	return len(d.Filter(ChangeBreaking)) > 0
+}
+
+// DiffSchemas lists the changes needed to go from the old schema to the new one. Built-in types and
+// directives are ignored, since they are a property of the server and not of the schema.
+func DiffSchemas(oldSchema, newSchema *ast.Schema) SchemaDiff {
+	differ := &schemaDiffer{diff: SchemaDiff{}}
+
+	// the root operation types
+	for _, root := range []struct {
+		operation string
+		old, new  *ast.Definition
+	}{
+		{"query", oldSchema.Query, newSchema.Query},
+		{"mutation", oldSchema.Mutation, newSchema.Mutation},
+		{"subscription", oldSchema.Subscription, newSchema.Subscription},
+	} {
+		oldName, newName := definitionName(root.old), definitionName(root.new)
+		if oldName == newName {
+			continue
+		}
+		severity := ChangeBreaking
+		if oldName == "" {
+			severity = ChangeSafe
+		}
+		differ.add(ChangeRootTypeChanged, severity, root.operation, "%s root type changed from %q to %q", root.operation, oldName, newName)
+	}
+
+	// the types
+	for _, name := range sortedKeys(oldSchema.Types, newSchema.Types) {
+		oldType, newType := oldSchema.Types[name], newSchema.Types[name]
+		if (oldType != nil && oldType.BuiltIn) || (newType != nil && newType.BuiltIn) {
+			continue
+		}
+
+		switch {
+		case newType == nil:
+			differ.add(ChangeTypeRemoved, ChangeBreaking, name, "%s was removed", name)
+		case oldType == nil:
+			differ.add(ChangeTypeAdded, ChangeSafe, name, "%s was added", name)
+		case oldType.Kind != newType.Kind:
+			differ.add(ChangeTypeKindChanged, ChangeBreaking, name, "%s changed from %s to %s", name, oldType.Kind, newType.Kind)
+		default:
+			differ.diffType(oldType, newType)
+		}
+	}
+
+	// the directives
+	for _, name := range sortedKeys(oldSchema.Directives, newSchema.Directives) {
+		oldDirective, newDirective := oldSchema.Directives[name], newSchema.Directives[name]
+		if isBuiltInDirective(oldDirective) || isBuiltInDirective(newDirective) {
+			continue
+		}
+
+		coordinate := "@" + name
+		switch {
+		case newDirective == nil:
+			differ.add(ChangeDirectiveRemoved, ChangeBreaking, coordinate, "%s was removed", coordinate)
+		case oldDirective == nil:
+			differ.add(ChangeDirectiveAdded, ChangeSafe, coordinate, "%s was added", coordinate)
+		default:
+			differ.diffDirective(oldDirective, newDirective)
+		}
+	}
+
+	return differ.diff
+}
+
+// schemaDiffer accumulates the changes between two schemas
+type schemaDiffer struct {
+	diff SchemaDiff
+}
+
+func (d *schemaDiffer) add(changeType ChangeType, severity ChangeSeverity, coordinate string, format string, args ...interface{}) {
+	d.diff = append(d.diff, &SchemaChange{
+		Type:       changeType,
+		Severity:   severity,
+		Coordinate: coordinate,
+		Message:    fmt.Sprintf(format, args...),
+	})
+}
+
+func (d *schemaDiffer) diffType(oldType, newType *ast.Definition) {
+	if oldType.Description != newType.Description {
+		d.add(ChangeTypeDescriptionChanged, ChangeSafe, newType.Name, "%s description changed", newType.Name)
+	}
+
+	switch newType.Kind {
+	case ast.Object, ast.Interface:
+		d.diffInterfaces(oldType, newType)
+		d.diffFields(oldType, newType)
+	case ast.InputObject:
+		d.diffInputFields(oldType, newType)
+	case ast.Union:
+		d.diffUnionMembers(oldType, newType)
+	case ast.Enum:
+		d.diffEnumValues(oldType, newType)
+	}
+}
+
+func (d *schemaDiffer) diffInterfaces(oldType, newType *ast.Definition) {
+	for _, name := range newType.Interfaces {
+		if !containsString(oldType.Interfaces, name) {
+			d.add(ChangeInterfaceImplementationAdded, ChangeDangerous, newType.Name, "%s now implements %s", newType.Name, name)
+		}
+	}
+	for _, name := range oldType.Interfaces {
+		if !containsString(newType.Interfaces, name) {
+			d.add(ChangeInterfaceImplementationRemoved, ChangeBreaking, newType.Name, "%s no longer implements %s", newType.Name, name)
+		}
+	}
+}
+
+func (d *schemaDiffer) diffFields(oldType, newType *ast.Definition) {
+	for _, oldField := range oldType.Fields {
+		if strings.HasPrefix(oldField.Name, "__") {
+			continue
+		}
+		coordinate := oldType.Name + "." + oldField.Name
+
+		newField := newType.Fields.ForName(oldField.Name)
+		if newField == nil {
+			d.add(ChangeFieldRemoved, ChangeBreaking, coordinate, "%s was removed", coordinate)
+			continue
+		}
+
+		if !isSafeOutputTypeChange(oldField.Type, newField.Type) {
+			d.add(ChangeFieldTypeChanged, ChangeBreaking, coordinate, "%s changed type from %s to %s", coordinate, oldField.Type, newField.Type)
+		} else if oldField.Type.String() != newField.Type.String() {
+			d.add(ChangeFieldTypeChanged, ChangeSafe, coordinate, "%s changed type from %s to %s", coordinate, oldField.Type, newField.Type)
+		}
+		if oldField.Description != newField.Description {
+			d.add(ChangeFieldDescriptionChanged, ChangeSafe, coordinate, "%s description changed", coordinate)
+		}
+		d.diffDeprecation(ChangeFieldDeprecationChanged, coordinate, oldField.Directives, newField.Directives)
+		d.diffArguments(coordinate, oldField.Arguments, newField.Arguments, ChangeDangerous)
+	}
+
+	for _, newField := range newType.Fields {
+		if strings.HasPrefix(newField.Name, "__") || oldType.Fields.ForName(newField.Name) != nil {
+			continue
+		}
+		coordinate := newType.Name + "." + newField.Name
+		d.add(ChangeFieldAdded, ChangeSafe, coordinate, "%s was added", coordinate)
+	}
+}
+
+// diffArguments compares the arguments of a field or directive. Adding an optional argument to a field
+// can change how the field resolves so it is dangerous, while it is safe for directives.
+func (d *schemaDiffer) diffArguments(parent string, oldArgs, newArgs ast.ArgumentDefinitionList, optionalAdded ChangeSeverity) {
+	for _, oldArg := range oldArgs {
+		coordinate := fmt.Sprintf("%s(%s:)", parent, oldArg.Name)
+
+		newArg := newArgs.ForName(oldArg.Name)
+		if newArg == nil {
+			d.add(ChangeArgumentRemoved, ChangeBreaking, coordinate, "%s was removed", coordinate)
+			continue
+		}
+
+		if !isSafeInputTypeChange(oldArg.Type, newArg.Type) {
+			d.add(ChangeArgumentTypeChanged, ChangeBreaking, coordinate, "%s changed type from %s to %s", coordinate, oldArg.Type, newArg.Type)
+		} else if oldArg.Type.String() != newArg.Type.String() {
+			d.add(ChangeArgumentTypeChanged, ChangeSafe, coordinate, "%s changed type from %s to %s", coordinate, oldArg.Type, newArg.Type)
+		}
+		if oldDefault, newDefault := defaultValueString(oldArg.DefaultValue), defaultValueString(newArg.DefaultValue); oldDefault != newDefault {
+			d.add(ChangeArgumentDefaultValueChanged, ChangeDangerous, coordinate, "%s default value changed from %s to %s", coordinate, oldDefault, newDefault)
+		}
+	}
+
+	for _, newArg := range newArgs {
+		if oldArgs.ForName(newArg.Name) != nil {
+			continue
+		}
+		coordinate := fmt.Sprintf("%s(%s:)", parent, newArg.Name)
+		if newArg.Type.NonNull && newArg.DefaultValue == nil {
+			d.add(ChangeArgumentAdded, ChangeBreaking, coordinate, "required argument %s was added", coordinate)
+		} else {
+			d.add(ChangeArgumentAdded, optionalAdded, coordinate, "optional argument %s was added", coordinate)
+		}
+	}
+}
+
+func (d *schemaDiffer) diffInputFields(oldType, newType *ast.Definition) {
+	for _, oldField := range oldType.Fields {
+		coordinate := oldType.Name + "." + oldField.Name
+
+		newField := newType.Fields.ForName(oldField.Name)
+		if newField == nil {
+			d.add(ChangeFieldRemoved, ChangeBreaking, coordinate, "%s was removed", coordinate)
+			continue
+		}
+
+		if !isSafeInputTypeChange(oldField.Type, newField.Type) {
+			d.add(ChangeFieldTypeChanged, ChangeBreaking, coordinate, "%s changed type from %s to %s", coordinate, oldField.Type, newField.Type)
+		} else if oldField.Type.String() != newField.Type.String() {
+			d.add(ChangeFieldTypeChanged, ChangeSafe, coordinate, "%s changed type from %s to %s", coordinate, oldField.Type, newField.Type)
+		}
+		if oldDefault, newDefault := defaultValueString(oldField.DefaultValue), defaultValueString(newField.DefaultValue); oldDefault != newDefault {
+			d.add(ChangeFieldDefaultValueChanged, ChangeDangerous, coordinate, "%s default value changed from %s to %s", coordinate, oldDefault, newDefault)
+		}
+		if oldField.Description != newField.Description {
+			d.add(ChangeFieldDescriptionChanged, ChangeSafe, coordinate, "%s description changed", coordinate)
+		}
+		d.diffDeprecation(ChangeFieldDeprecationChanged, coordinate, oldField.Directives, newField.Directives)
+	}
+
+	for _, newField := range newType.Fields {
+		if oldType.Fields.ForName(newField.Name) != nil {
+			continue
+		}
+		coordinate := newType.Name + "." + newField.Name
+		if newField.Type.NonNull && newField.DefaultValue == nil {
+			d.add(ChangeFieldAdded, ChangeBreaking, coordinate, "required input field %s was added", coordinate)
+		} else {
+			d.add(ChangeFieldAdded, ChangeDangerous, coordinate, "optional input field %s was added", coordinate)
+		}
+	}
+}
+
+func (d *schemaDiffer) diffUnionMembers(oldType, newType *ast.Definition) {
+	for _, name := range newType.Types {
+		if !containsString(oldType.Types, name) {
+			d.add(ChangeUnionMemberAdded, ChangeDangerous, newType.Name, "%s was added to union %s", name, newType.Name)
+		}
+	}
+	for _, name := range oldType.Types {
+		if !containsString(newType.Types, name) {
+			d.add(ChangeUnionMemberRemoved, ChangeBreaking, newType.Name, "%s was removed from union %s", name, newType.Name)
+		}
+	}
+}
+
+func (d *schemaDiffer) diffEnumValues(oldType, newType *ast.Definition) {
+	for _, oldValue := range oldType.EnumValues {
+		coordinate := oldType.Name + "." + oldValue.Name
+
+		newValue := newType.EnumValues.ForName(oldValue.Name)
+		if newValue == nil {
+			d.add(ChangeEnumValueRemoved, ChangeBreaking, coordinate, "%s was removed", coordinate)
+			continue
+		}
+		d.diffDeprecation(ChangeEnumValueDeprecationChanged, coordinate, oldValue.Directives, newValue.Directives)
+	}
+
+	for _, newValue := range newType.EnumValues {
+		if oldType.EnumValues.ForName(newValue.Name) == nil {
+			coordinate := newType.Name + "." + newValue.Name
+			d.add(ChangeEnumValueAdded, ChangeDangerous, coordinate, "%s was added", coordinate)
+		}
+	}
+}
+
+func (d *schemaDiffer) diffDeprecation(changeType ChangeType, coordinate string, oldDirectives, newDirectives ast.DirectiveList) {
+	oldDeprecated, oldReason := introspectionMarshalDeprecation(oldDirectives)
+	newDeprecated, newReason := introspectionMarshalDeprecation(newDirectives)
+	switch {
+	case !oldDeprecated && newDeprecated:
+		d.add(changeType, ChangeSafe, coordinate, "%s was deprecated", coordinate)
+	case oldDeprecated && !newDeprecated:
+		d.add(changeType, ChangeSafe, coordinate, "%s is no longer deprecated", coordinate)
+	case oldReason != newReason:
+		d.add(changeType, ChangeSafe, coordinate, "%s deprecation reason changed", coordinate)
+	}
+}
+
+func (d *schemaDiffer) diffDirective(oldDirective, newDirective *ast.DirectiveDefinition) {
+	coordinate := "@" + newDirective.Name
+
+	for _, location := range newDirective.Locations {
+		if !containsLocation(oldDirective.Locations, location) {
+			d.add(ChangeDirectiveLocationAdded, ChangeSafe, coordinate, "%s can now be used on %s", coordinate, location)
+		}
+	}
+	for _, location := range oldDirective.Locations {
+		if !containsLocation(newDirective.Locations, location) {
+			d.add(ChangeDirectiveLocationRemoved, ChangeBreaking, coordinate, "%s can no longer be used on %s", coordinate, location)
+		}
+	}
+
+	switch {
+	case oldDirective.IsRepeatable && !newDirective.IsRepeatable:
+		d.add(ChangeDirectiveRepeatableChanged, ChangeBreaking, coordinate, "%s is no longer repeatable", coordinate)
+	case !oldDirective.IsRepeatable && newDirective.IsRepeatable:
+		d.add(ChangeDirectiveRepeatableChanged, ChangeSafe, coordinate, "%s is now repeatable", coordinate)
+	}
+
+	d.diffArguments(coordinate, oldDirective.Arguments, newDirective.Arguments, ChangeSafe)
+}
+
+// isSafeOutputTypeChange returns true if every value of the new type is a valid value of the old type,
+// i.e. the new type is the same or more strict
+func isSafeOutputTypeChange(oldType, newType *ast.Type) bool {
+	if newType.NonNull && !oldType.NonNull {
+		return isSafeOutputTypeChange(oldType, nullableType(newType))
+	}
+	if oldType.NonNull != newType.NonNull {
+		return false
+	}
+	if oldType.Elem != nil || newType.Elem != nil {
+		return oldType.Elem != nil && newType.Elem != nil && isSafeOutputTypeChange(oldType.Elem, newType.Elem)
+	}
+	return oldType.NamedType == newType.NamedType
+}
+
+// isSafeInputTypeChange returns true if every value of the old type is a valid value of the new type,
+// i.e. the new type is the same or less strict
+func isSafeInputTypeChange(oldType, newType *ast.Type) bool {
+	if oldType.NonNull && !newType.NonNull {
+		return isSafeInputTypeChange(nullableType(oldType), newType)
+	}
+	if oldType.NonNull != newType.NonNull {
+		return false
+	}
+	if oldType.Elem != nil || newType.Elem != nil {
+		return oldType.Elem != nil && newType.Elem != nil && isSafeInputTypeChange(oldType.Elem, newType.Elem)
+	}
+	return oldType.NamedType == newType.NamedType
+}
+
+func nullableType(typ *ast.Type) *ast.Type {
+	nullable := *typ
+	nullable.NonNull = false
+	return &nullable
+}
+
+func defaultValueString(value *ast.Value) string {
+	if value == nil {
+		return "none"
+	}
+	return value.String()
+}
+
+func definitionName(definition *ast.Definition) string {
+	if definition == nil {
+		return ""
+	}
+	return definition.Name
+}
+
+func isBuiltInDirective(directive *ast.DirectiveDefinition) bool {
+	return directive != nil && directive.Position != nil && directive.Position.Src != nil && directive.Position.Src.BuiltIn
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsLocation(list []ast.DirectiveLocation, value ast.DirectiveLocation) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedKeys returns the union of the keys of both maps, sorted
+func sortedKeys[V any](a, b map[string]V) []string {
+	keys := []string{}
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
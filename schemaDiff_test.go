@@ -0,0 +1,205 @@
+package graphql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffSchemas(t *testing.T) {
+	t.Parallel()
+	for _, row := range []struct {
+		Message  string
+		Old      string
+		New      string
+		Expected []SchemaChange
+	}{
+		{
+			Message: "no changes",
+			Old:     `type Query { a: String }`,
+			New:     `type Query { a: String }`,
+		},
+		{
+			Message: "type added and removed",
+			Old:     `type Query { a: String } type A { a: String }`,
+			New:     `type Query { a: String } type B { a: String }`,
+			Expected: []SchemaChange{
+				{Type: ChangeTypeRemoved, Severity: ChangeBreaking, Coordinate: "A"},
+				{Type: ChangeTypeAdded, Severity: ChangeSafe, Coordinate: "B"},
+			},
+		},
+		{
+			Message: "type kind changed",
+			Old:     `type Query { a: A } type A { a: String }`,
+			New:     `type Query { a: A } interface A { a: String }`,
+			Expected: []SchemaChange{
+				{Type: ChangeTypeKindChanged, Severity: ChangeBreaking, Coordinate: "A"},
+			},
+		},
+		{
+			Message: "root type changed",
+			Old:     `type Query { a: String }`,
+			New:     `schema { query: RootQuery } type RootQuery { a: String }`,
+			Expected: []SchemaChange{
+				{Type: ChangeRootTypeChanged, Severity: ChangeBreaking, Coordinate: "query"},
+				{Type: ChangeTypeRemoved, Severity: ChangeBreaking, Coordinate: "Query"},
+				{Type: ChangeTypeAdded, Severity: ChangeSafe, Coordinate: "RootQuery"},
+			},
+		},
+		{
+			Message: "mutation root added",
+			Old:     `type Query { a: String }`,
+			New:     `type Query { a: String } type Mutation { a: String }`,
+			Expected: []SchemaChange{
+				{Type: ChangeRootTypeChanged, Severity: ChangeSafe, Coordinate: "mutation"},
+				{Type: ChangeTypeAdded, Severity: ChangeSafe, Coordinate: "Mutation"},
+			},
+		},
+		{
+			Message: "fields",
+			Old:     `type Query { a: String, b: String, c: String!, d: [String], e: String }`,
+			New:     `type Query { a: String, c: String, d: [String!]!, e: Int, f: String }`,
+			Expected: []SchemaChange{
+				{Type: ChangeFieldRemoved, Severity: ChangeBreaking, Coordinate: "Query.b"},
+				{Type: ChangeFieldTypeChanged, Severity: ChangeBreaking, Coordinate: "Query.c"},
+				{Type: ChangeFieldTypeChanged, Severity: ChangeSafe, Coordinate: "Query.d"},
+				{Type: ChangeFieldTypeChanged, Severity: ChangeBreaking, Coordinate: "Query.e"},
+				{Type: ChangeFieldAdded, Severity: ChangeSafe, Coordinate: "Query.f"},
+			},
+		},
+		{
+			Message: "field descriptions and deprecation",
+			Old:     `type Query { "old" a: String, b: String }`,
+			New:     `type Query { "new" a: String, b: String @deprecated }`,
+			Expected: []SchemaChange{
+				{Type: ChangeFieldDescriptionChanged, Severity: ChangeSafe, Coordinate: "Query.a"},
+				{Type: ChangeFieldDeprecationChanged, Severity: ChangeSafe, Coordinate: "Query.b"},
+			},
+		},
+		{
+			Message: "arguments",
+			Old:     `type Query { a(x: Int, y: Int!, z: Int = 1, w: Int): String }`,
+			New:     `type Query { a(x: Int!, y: Int, z: Int = 2, v: Int, u: Int!, t: Int! = 1): String }`,
+			Expected: []SchemaChange{
+				{Type: ChangeArgumentTypeChanged, Severity: ChangeBreaking, Coordinate: "Query.a(x:)"},
+				{Type: ChangeArgumentTypeChanged, Severity: ChangeSafe, Coordinate: "Query.a(y:)"},
+				{Type: ChangeArgumentDefaultValueChanged, Severity: ChangeDangerous, Coordinate: "Query.a(z:)"},
+				{Type: ChangeArgumentRemoved, Severity: ChangeBreaking, Coordinate: "Query.a(w:)"},
+				{Type: ChangeArgumentAdded, Severity: ChangeDangerous, Coordinate: "Query.a(v:)"},
+				{Type: ChangeArgumentAdded, Severity: ChangeBreaking, Coordinate: "Query.a(u:)"},
+				{Type: ChangeArgumentAdded, Severity: ChangeDangerous, Coordinate: "Query.a(t:)"},
+			},
+		},
+		{
+			Message: "input fields",
+			Old:     `type Query { a(i: I): String } input I { a: Int, b: Int!, c: Int }`,
+			New:     `type Query { a(i: I): String } input I { a: Int = 1, b: Int, d: Int, e: Int! }`,
+			Expected: []SchemaChange{
+				{Type: ChangeFieldDefaultValueChanged, Severity: ChangeDangerous, Coordinate: "I.a"},
+				{Type: ChangeFieldTypeChanged, Severity: ChangeSafe, Coordinate: "I.b"},
+				{Type: ChangeFieldRemoved, Severity: ChangeBreaking, Coordinate: "I.c"},
+				{Type: ChangeFieldAdded, Severity: ChangeDangerous, Coordinate: "I.d"},
+				{Type: ChangeFieldAdded, Severity: ChangeBreaking, Coordinate: "I.e"},
+			},
+		},
+		{
+			Message: "enum values",
+			Old:     `type Query { a: E } enum E { A B C }`,
+			New:     `type Query { a: E } enum E { A @deprecated(reason: "use D") B D }`,
+			Expected: []SchemaChange{
+				{Type: ChangeEnumValueDeprecationChanged, Severity: ChangeSafe, Coordinate: "E.A"},
+				{Type: ChangeEnumValueRemoved, Severity: ChangeBreaking, Coordinate: "E.C"},
+				{Type: ChangeEnumValueAdded, Severity: ChangeDangerous, Coordinate: "E.D"},
+			},
+		},
+		{
+			Message: "union members",
+			Old:     `type Query { a: U } union U = A | B type A { a: String } type B { a: String } type C { a: String }`,
+			New:     `type Query { a: U } union U = A | C type A { a: String } type B { a: String } type C { a: String }`,
+			Expected: []SchemaChange{
+				{Type: ChangeUnionMemberAdded, Severity: ChangeDangerous, Coordinate: "U"},
+				{Type: ChangeUnionMemberRemoved, Severity: ChangeBreaking, Coordinate: "U"},
+			},
+		},
+		{
+			Message: "interface implementations",
+			Old:     `type Query { a: A } interface I { id: ID } interface J { id: ID } type A implements I { id: ID }`,
+			New:     `type Query { a: A } interface I { id: ID } interface J { id: ID } type A implements J { id: ID }`,
+			Expected: []SchemaChange{
+				{Type: ChangeInterfaceImplementationAdded, Severity: ChangeDangerous, Coordinate: "A"},
+				{Type: ChangeInterfaceImplementationRemoved, Severity: ChangeBreaking, Coordinate: "A"},
+			},
+		},
+		{
+			Message: "directives",
+			Old: `
+				type Query { a: String }
+				directive @a on FIELD_DEFINITION
+				directive @b(x: Int, y: Int) repeatable on FIELD_DEFINITION | OBJECT
+			`,
+			New: `
+				type Query { a: String }
+				directive @b(x: Int!, z: Int, w: Int!) on FIELD_DEFINITION | ENUM
+				directive @c on FIELD_DEFINITION
+			`,
+			Expected: []SchemaChange{
+				{Type: ChangeDirectiveRemoved, Severity: ChangeBreaking, Coordinate: "@a"},
+				{Type: ChangeDirectiveLocationAdded, Severity: ChangeSafe, Coordinate: "@b"},
+				{Type: ChangeDirectiveLocationRemoved, Severity: ChangeBreaking, Coordinate: "@b"},
+				{Type: ChangeDirectiveRepeatableChanged, Severity: ChangeBreaking, Coordinate: "@b"},
+				{Type: ChangeArgumentTypeChanged, Severity: ChangeBreaking, Coordinate: "@b(x:)"},
+				{Type: ChangeArgumentRemoved, Severity: ChangeBreaking, Coordinate: "@b(y:)"},
+				{Type: ChangeArgumentAdded, Severity: ChangeSafe, Coordinate: "@b(z:)"},
+				{Type: ChangeArgumentAdded, Severity: ChangeBreaking, Coordinate: "@b(w:)"},
+				{Type: ChangeDirectiveAdded, Severity: ChangeSafe, Coordinate: "@c"},
+			},
+		},
+	} {
+		row := row
+		t.Run(row.Message, func(t *testing.T) {
+			t.Parallel()
+			oldSchema, err := LoadSchema(row.Old)
+			require.NoError(t, err)
+			newSchema, err := LoadSchema(row.New)
+			require.NoError(t, err)
+
+			diff := DiffSchemas(oldSchema, newSchema)
+
+			actual := []SchemaChange{}
+			for _, change := range diff {
+				assert.NotEmpty(t, change.Message)
+				actual = append(actual, SchemaChange{Type: change.Type, Severity: change.Severity, Coordinate: change.Coordinate})
+			}
+			expected := row.Expected
+			if expected == nil {
+				expected = []SchemaChange{}
+			}
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func TestDiffSchemas_introspected(t *testing.T) {
+	t.Parallel()
+	// built-in types and directives differ between parsed and introspected schemas and should not show up
+	schema, err := LoadSchema(`type Query { a(x: Int = 1): String @deprecated(reason: "gone") }`)
+	require.NoError(t, err)
+	introspected, err := BuildSchemaFromIntrospection(NewIntrospectionQueryResult(schema))
+	require.NoError(t, err)
+
+	assert.Empty(t, DiffSchemas(schema, introspected))
+}
+
+func TestSchemaDiff_Filter(t *testing.T) {
+	t.Parallel()
+	diff := SchemaDiff{
+		{Type: ChangeFieldAdded, Severity: ChangeSafe},
+		{Type: ChangeEnumValueAdded, Severity: ChangeDangerous},
+	}
+	assert.Equal(t, SchemaDiff{diff[1]}, diff.Filter(ChangeDangerous))
+	assert.False(t, diff.HasBreakingChanges())
+
+	diff = append(diff, &SchemaChange{Type: ChangeFieldRemoved, Severity: ChangeBreaking})
+	assert.True(t, diff.HasBreakingChanges())
+}
@@ -0,0 +1,176 @@
+package graphql
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// defaultDeprecationReason is the reason servers report for a @deprecated without one
+const defaultDeprecationReason = "No longer supported"
+
+// SchemaFingerprintOption configures SchemaFingerprint and TypeFingerprint
+type SchemaFingerprintOption func(opts *schemaFingerprintOptions)
+
+type schemaFingerprintOptions struct {
+	ignoreDescriptions bool
+}
+
+// SchemaFingerprintWithoutDescriptions leaves descriptions out of the fingerprint, so editing them
+// doesn't change it
+func SchemaFingerprintWithoutDescriptions() SchemaFingerprintOption {
+	return func(opts *schemaFingerprintOptions) {
+		opts.ignoreDescriptions = true
+	}
+}
+
+// SchemaFingerprint returns a hash of the parts of a schema that introspection exposes. It does not depend on the
+// order of definitions, fields, arguments or values, nor on source positions, so the same schema gets the same
+// fingerprint whether it was loaded with LoadSchema or built by IntrospectAPI. That takes the features IntrospectAPI
+// detects by default: without them, repeatable directives, @specifiedBy and @oneOf are missing from the introspected
+// schema and change its fingerprint. Built-in types and directives are left out since they depend on the server and
+// not on the schema.
+func SchemaFingerprint(schema *ast.Schema, opts ...SchemaFingerprintOption) string {
+	options := newSchemaFingerprintOptions(opts)
+
+	// the schema is described by its root types and the fingerprint of everything in it
+	description := map[string]interface{}{
+		"query":        definitionName(schema.Query),
+		"mutation":     definitionName(schema.Mutation),
+		"subscription": definitionName(schema.Subscription),
+		"types":        TypeFingerprints(schema, opts...),
+		"directives":   directiveFingerprints(schema, options),
+	}
+	if !options.ignoreDescriptions {
+		description["description"] = schema.Description
+	}
+	return fingerprint(description)
+}
+
+// TypeFingerprint returns the fingerprint of a single type of the schema, see SchemaFingerprint. The types that
+// implement an interface are not part of the interface's fingerprint, they are part of their own.
+func TypeFingerprint(schema *ast.Schema, definition *ast.Definition, opts ...SchemaFingerprintOption) string {
+	options := newSchemaFingerprintOptions(opts)
+
+	result := introspectionMarshalType(schema, definition)
+	if definition.Kind == ast.Interface {
+		result.PossibleTypes = nil
+	}
+	if options.ignoreDescriptions {
+		result.Description = ""
+	}
+
+	sortIntrospectionTypeRefs(result.Interfaces)
+	sortIntrospectionTypeRefs(result.PossibleTypes)
+	sort.Slice(result.Fields, func(i, j int) bool {
+		return result.Fields[i].Name < result.Fields[j].Name
+	})
+	for i := range result.Fields {
+		field := &result.Fields[i]
+		normalizeFingerprintInputValues(field.Args, options)
+		field.DeprecationReason = fingerprintDeprecationReason(field.IsDeprecated, field.DeprecationReason)
+		if options.ignoreDescriptions {
+			field.Description = ""
+		}
+	}
+	normalizeFingerprintInputValues(result.InputFields, options)
+	sort.Slice(result.EnumValues, func(i, j int) bool {
+		return result.EnumValues[i].Name < result.EnumValues[j].Name
+	})
+	for i := range result.EnumValues {
+		value := &result.EnumValues[i]
+		value.DeprecationReason = fingerprintDeprecationReason(value.IsDeprecated, value.DeprecationReason)
+		if options.ignoreDescriptions {
+			value.Description = ""
+		}
+	}
+
+	return fingerprint(result)
+}
+
+// TypeFingerprints returns the fingerprint of every type in the schema except the built-in ones, by name.
+// Comparing them between two versions of a schema shows which types changed.
+func TypeFingerprints(schema *ast.Schema, opts ...SchemaFingerprintOption) map[string]string {
+	fingerprints := map[string]string{}
+	for name, definition := range schema.Types {
+		if !definition.BuiltIn && !strings.HasPrefix(name, "__") {
+			fingerprints[name] = TypeFingerprint(schema, definition, opts...)
+		}
+	}
+	return fingerprints
+}
+
+func directiveFingerprints(schema *ast.Schema, options *schemaFingerprintOptions) map[string]string {
+	fingerprints := map[string]string{}
+	for name, directive := range schema.Directives {
+		if isBuiltInDirective(directive) {
+			continue
+		}
+
+		locations := []string{}
+		for _, location := range directive.Locations {
+			locations = append(locations, string(location))
+		}
+		sort.Strings(locations)
+
+		result := IntrospectionQueryDirective{
+			Name:         directive.Name,
+			Locations:    locations,
+			Args:         introspectionMarshalArgList(schema, directive.Arguments),
+			IsRepeatable: directive.IsRepeatable,
+		}
+		if !options.ignoreDescriptions {
+			result.Description = directive.Description
+		}
+		normalizeFingerprintInputValues(result.Args, options)
+
+		fingerprints[name] = fingerprint(result)
+	}
+	return fingerprints
+}
+
+func newSchemaFingerprintOptions(opts []SchemaFingerprintOption) *schemaFingerprintOptions {
+	options := &schemaFingerprintOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+func normalizeFingerprintInputValues(values []IntrospectionInputValue, options *schemaFingerprintOptions) {
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].Name < values[j].Name
+	})
+	for i := range values {
+		values[i].DeprecationReason = fingerprintDeprecationReason(values[i].IsDeprecated, values[i].DeprecationReason)
+		if options.ignoreDescriptions {
+			values[i].Description = ""
+		}
+	}
+}
+
+// fingerprintDeprecationReason fills in the reason servers report when a schema doesn't give one
+func fingerprintDeprecationReason(isDeprecated bool, reason string) string {
+	if isDeprecated && reason == "" {
+		return defaultDeprecationReason
+	}
+	return reason
+}
+
+func sortIntrospectionTypeRefs(refs []IntrospectionTypeRef) {
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[i].Name < refs[j].Name
+	})
+}
+
+// fingerprint hashes the JSON form of a value. Maps are marshaled with sorted keys so the result is stable.
+func fingerprint(value interface{}) string {
+	// the values are built from plain structs, maps and strings, which always marshal
+	marshaled, _ := json.Marshal(value)
+	sum := sha256.Sum256(marshaled)
+	return hex.EncodeToString(sum[:])
+}
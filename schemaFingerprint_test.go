@@ -0,0 +1,180 @@
+package graphql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const schemaFingerprintTestSchema = `
+	"the api"
+	schema {
+		query: Query
+	}
+
+	type Query {
+		"find a user"
+		user(id: ID!, locale: String = "en"): User
+		users(first: Int = 10, status: Status = ACTIVE): [User!]! @deprecated
+	}
+
+	interface Node {
+		id: ID!
+	}
+
+	type User implements Node {
+		id: ID!
+		name: String
+		status: Status
+	}
+
+	enum Status {
+		ACTIVE
+		BANNED @deprecated(reason: "use ACTIVE")
+	}
+
+	union Result = User
+
+	input Filter {
+		status: Status = ACTIVE
+		names: [String!]
+	}
+
+	scalar Date @specifiedBy(url: "https://tools.ietf.org/html/rfc3339")
+
+	directive @cached(ttl: Int = 60) repeatable on FIELD_DEFINITION | OBJECT
+
+	directive @oneOf on INPUT_OBJECT
+
+	input By @oneOf {
+		id: ID
+		name: String
+	}
+`
+
+// schemaFingerprintTestReordered is schemaFingerprintTestSchema with everything in a different order
+const schemaFingerprintTestReordered = `
+	input By @oneOf {
+		name: String
+		id: ID
+	}
+
+	directive @oneOf on INPUT_OBJECT
+
+	directive @cached(ttl: Int = 60) repeatable on OBJECT | FIELD_DEFINITION
+
+	scalar Date @specifiedBy(url: "https://tools.ietf.org/html/rfc3339")
+
+	input Filter {
+		names: [String!]
+		status: Status = ACTIVE
+	}
+
+	union Result = User
+
+	enum Status {
+		BANNED @deprecated(reason: "use ACTIVE")
+		ACTIVE
+	}
+
+	type User implements Node {
+		status: Status
+		name: String
+		id: ID!
+	}
+
+	interface Node {
+		id: ID!
+	}
+
+	"the api"
+	schema {
+		query: Query
+	}
+
+	type Query {
+		users(status: Status = ACTIVE, first: Int = 10): [User!]! @deprecated(reason: "No longer supported")
+		"find a user"
+		user(locale: String = "en", id: ID!): User
+	}
+`
+
+func TestSchemaFingerprint_stable(t *testing.T) {
+	t.Parallel()
+	schema, err := LoadSchema(schemaFingerprintTestSchema)
+	require.NoError(t, err)
+	reordered, err := LoadSchema(schemaFingerprintTestReordered)
+	require.NoError(t, err)
+
+	// definition order doesn't matter
+	assert.Equal(t, SchemaFingerprint(schema), SchemaFingerprint(reordered))
+	assert.Equal(t, TypeFingerprints(schema), TypeFingerprints(reordered))
+
+	// neither does where the schema came from, with the default introspection options
+	introspected, err := IntrospectAPI(NewSchemaIntrospectionQueryer(schema))
+	require.NoError(t, err)
+	assert.Equal(t, SchemaFingerprint(schema), SchemaFingerprint(introspected))
+	assert.Equal(t, TypeFingerprints(schema), TypeFingerprints(introspected))
+
+	// and it's the same every time
+	assert.Equal(t, SchemaFingerprint(schema), SchemaFingerprint(schema))
+	assert.Len(t, SchemaFingerprint(schema), 64)
+}
+
+func TestSchemaFingerprint_changes(t *testing.T) {
+	t.Parallel()
+	schema, err := LoadSchema(`type Query { a: String, b: B } "b" type B { c: Int }`)
+	require.NoError(t, err)
+
+	for _, row := range []struct {
+		Message       string
+		Schema        string
+		ChangedTypes  []string
+		OnlyDescribed bool
+	}{
+		{
+			Message:      "field type",
+			Schema:       `type Query { a: String!, b: B } "b" type B { c: Int }`,
+			ChangedTypes: []string{"Query"},
+		},
+		{
+			Message:      "nested field",
+			Schema:       `type Query { a: String, b: B } "b" type B { c: Int, d: Int }`,
+			ChangedTypes: []string{"B"},
+		},
+		{
+			Message:      "new type",
+			Schema:       `type Query { a: String, b: B } "b" type B { c: Int } type C { c: Int }`,
+			ChangedTypes: []string{"C"},
+		},
+		{
+			Message:       "description",
+			Schema:        `type Query { a: String, b: B } "a b" type B { c: Int }`,
+			ChangedTypes:  []string{"B"},
+			OnlyDescribed: true,
+		},
+	} {
+		row := row
+		t.Run(row.Message, func(t *testing.T) {
+			t.Parallel()
+			changed, err := LoadSchema(row.Schema)
+			require.NoError(t, err)
+
+			assert.NotEqual(t, SchemaFingerprint(schema), SchemaFingerprint(changed))
+
+			before, after := TypeFingerprints(schema), TypeFingerprints(changed)
+			changedTypes := []string{}
+			for _, name := range sortedKeys(before, after) {
+				if before[name] != after[name] {
+					changedTypes = append(changedTypes, name)
+				}
+			}
+			assert.Equal(t, row.ChangedTypes, changedTypes)
+
+			// descriptions can be left out
+			withoutDescriptions := SchemaFingerprintWithoutDescriptions()
+			assert.Equal(t, row.OnlyDescribed, SchemaFingerprint(schema, withoutDescriptions) == SchemaFingerprint(changed, withoutDescriptions))
+		})
+	}
+}
@@ -0,0 +1,355 @@
+package graphql
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// LintSeverity describes how much a lint problem matters
+type LintSeverity string
+
+const (
+	// LintError problems break a convention that must be followed
+	LintError LintSeverity = "ERROR"
+	// LintWarning problems break a convention that should be followed
+	LintWarning LintSeverity = "WARNING"
+)
+
+// LintProblem is a single violation of a lint rule
+type LintProblem struct {
+	Rule     string
+	Severity LintSeverity
+	// Coordinate is the schema coordinate of the offending element, e.g. User.friends(first:) or Status.ACTIVE
+	Coordinate string
+	Message    string
+}
+
+func (p *LintProblem) String() string {
+	return fmt.Sprintf("%s: %s: %s (%s)", p.Severity, p.Coordinate, p.Message, p.Rule)
+}
+
+// LintResult is the list of problems found in a schema
+type LintResult []*LintProblem
+
+// Filter returns the problems with the given severity
+func (r LintResult) Filter(severity LintSeverity) LintResult {
+	result := LintResult{}
+	for _, problem := range r {
+		if problem.Severity == severity {
+			result = append(result, problem)
+		}
+	}
+	return result
+}
+
+// HasErrors returns true if any of the problems are errors
+func (r LintResult) HasErrors() bool {
+	return len(r.Filter(LintError)) > 0
+}
+
+// LintReportFunc is passed to a LintRule to report a problem with the element at the given coordinate
+type LintReportFunc func(coordinate string, format string, args ...interface{})
+
+// LintRule checks a schema for violations of a convention
+type LintRule interface {
+	// Name identifies the rule in the problems it reports
+	Name() string
+	// Severity is the severity of the problems the rule reports
+	Severity() LintSeverity
+	// Lint calls report for every violation of the rule in the schema
+	Lint(schema *ast.Schema, report LintReportFunc)
+}
+
+// NewLintRule returns a LintRule that calls the lint function, for conventions that aren't built in
+func NewLintRule(name string, severity LintSeverity, lint func(schema *ast.Schema, report LintReportFunc)) LintRule {
+	return &lintRule{name: name, severity: severity, lint: lint}
+}
+
+type lintRule struct {
+	name     string
+	severity LintSeverity
+	lint     func(schema *ast.Schema, report LintReportFunc)
+}
+
+func (r *lintRule) Name() string {
+	return r.name
+}
+
+func (r *lintRule) Severity() LintSeverity {
+	return r.severity
+}
+
+func (r *lintRule) Lint(schema *ast.Schema, report LintReportFunc) {
+	r.lint(schema, report)
+}
+
+// LintWithSeverity returns a copy of the rule that reports its problems with another severity
+func LintWithSeverity(rule LintRule, severity LintSeverity) LintRule {
+	return &lintRule{name: rule.Name(), severity: severity, lint: rule.Lint}
+}
+
+// DefaultLintRules returns every built-in rule with its default severity
+func DefaultLintRules() []LintRule {
+	return []LintRule{
+		LintTypeNames(),
+		LintFieldNames(),
+		LintEnumValueNames(),
+		LintDescriptions(),
+		LintDeprecationReasons(),
+		LintRelayConnections(),
+		LintNonNullListItems(),
+		LintInputTypeSuffix("Input"),
+	}
+}
+
+// LintSchema checks the schema against the rules, or against DefaultLintRules if none are given. Built-in types
+// and directives, as well as introspection fields, are not checked, so a schema gets the same result whether it
+// comes from LoadSchema or IntrospectAPI.
+func LintSchema(schema *ast.Schema, rules ...LintRule) LintResult {
+	if len(rules) == 0 {
+		rules = DefaultLintRules()
+	}
+
+	result := LintResult{}
+	for _, rule := range rules {
+		rule.Lint(schema, func(coordinate string, format string, args ...interface{}) {
+			result = append(result, &LintProblem{
+				Rule:       rule.Name(),
+				Severity:   rule.Severity(),
+				Coordinate: coordinate,
+				Message:    fmt.Sprintf(format, args...),
+			})
+		})
+	}
+	return result
+}
+
+var (
+	lintPascalCase = regexp.MustCompile(`^[A-Z][a-zA-Z0-9]*$`)
+	lintCamelCase  = regexp.MustCompile(`^[a-z][a-zA-Z0-9]*$`)
+	lintUpperCase  = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+)
+
+// lintName returns true if the name matches the pattern. Names starting with an underscore, like the ones
+// federation adds, follow their own convention and always match.
+func lintName(pattern *regexp.Regexp, name string) bool {
+	return strings.HasPrefix(name, "_") || pattern.MatchString(name)
+}
+
+// LintTypeNames returns a rule that reports types whose name is not PascalCase
+func LintTypeNames() LintRule {
+	return NewLintRule("type-names", LintError, func(schema *ast.Schema, report LintReportFunc) {
+		for _, definition := range lintDefinitions(schema) {
+			if !lintName(lintPascalCase, definition.Name) {
+				report(definition.Name, "type name must be PascalCase")
+			}
+		}
+	})
+}
+
+// LintFieldNames returns a rule that reports fields, input fields and arguments whose name is not camelCase
+func LintFieldNames() LintRule {
+	return NewLintRule("field-names", LintError, func(schema *ast.Schema, report LintReportFunc) {
+		for _, definition := range lintDefinitions(schema) {
+			for _, field := range lintFields(definition) {
+				coordinate := definition.Name + "." + field.Name
+				if !lintName(lintCamelCase, field.Name) {
+					report(coordinate, "field name must be camelCase")
+				}
+				for _, arg := range field.Arguments {
+					if !lintName(lintCamelCase, arg.Name) {
+						report(fmt.Sprintf("%s(%s:)", coordinate, arg.Name), "argument name must be camelCase")
+					}
+				}
+			}
+		}
+	})
+}
+
+// LintEnumValueNames returns a rule that reports enum values that are not UPPER_CASE
+func LintEnumValueNames() LintRule {
+	return NewLintRule("enum-value-names", LintError, func(schema *ast.Schema, report LintReportFunc) {
+		for _, definition := range lintDefinitions(schema) {
+			for _, value := range definition.EnumValues {
+				if !lintName(lintUpperCase, value.Name) {
+					report(definition.Name+"."+value.Name, "enum value must be UPPER_CASE")
+				}
+			}
+		}
+	})
+}
+
+// LintDescriptions returns a rule that reports types, fields and input fields without a description
+func LintDescriptions() LintRule {
+	return NewLintRule("descriptions", LintWarning, func(schema *ast.Schema, report LintReportFunc) {
+		for _, definition := range lintDefinitions(schema) {
+			if strings.TrimSpace(definition.Description) == "" {
+				report(definition.Name, "type must have a description")
+			}
+			for _, field := range lintFields(definition) {
+				if strings.TrimSpace(field.Description) == "" {
+					report(definition.Name+"."+field.Name, "field must have a description")
+				}
+			}
+		}
+	})
+}
+
+// LintDeprecationReasons returns a rule that reports deprecated elements without a reason. The reason servers
+// report when none was given counts as missing, since that's all introspection can tell.
+func LintDeprecationReasons() LintRule {
+	return NewLintRule("deprecation-reasons", LintError, func(schema *ast.Schema, report LintReportFunc) {
+		check := func(coordinate string, directives ast.DirectiveList) {
+			deprecated, reason := introspectionMarshalDeprecation(directives)
+			if deprecated && (strings.TrimSpace(reason) == "" || reason == defaultDeprecationReason) {
+				report(coordinate, "deprecation must have a reason")
+			}
+		}
+
+		for _, definition := range lintDefinitions(schema) {
+			for _, field := range lintFields(definition) {
+				coordinate := definition.Name + "." + field.Name
+				check(coordinate, field.Directives)
+				for _, arg := range field.Arguments {
+					check(fmt.Sprintf("%s(%s:)", coordinate, arg.Name), arg.Directives)
+				}
+			}
+			for _, value := range definition.EnumValues {
+				check(definition.Name+"."+value.Name, value.Directives)
+			}
+		}
+	})
+}
+
+// LintRelayConnections returns a rule that checks that types named like a Relay connection have the shape the
+// Relay cursor connections specification describes, and that the fields returning them can be paginated
+func LintRelayConnections() LintRule {
+	return NewLintRule("relay-connections", LintError, func(schema *ast.Schema, report LintReportFunc) {
+		hasConnections := false
+		for _, definition := range lintDefinitions(schema) {
+			if definition.Kind != ast.Object && definition.Kind != ast.Interface {
+				continue
+			}
+
+			if strings.HasSuffix(definition.Name, "Connection") {
+				hasConnections = true
+				lintConnection(schema, definition, report)
+			}
+
+			// fields returning a connection must take pagination arguments
+			for _, field := range lintFields(definition) {
+				fieldType := schema.Types[field.Type.Name()]
+				if field.Type.Elem != nil || fieldType == nil || !strings.HasSuffix(fieldType.Name, "Connection") {
+					continue
+				}
+				forward := field.Arguments.ForName("first") != nil && field.Arguments.ForName("after") != nil
+				backward := field.Arguments.ForName("last") != nil && field.Arguments.ForName("before") != nil
+				if !forward && !backward {
+					report(definition.Name+"."+field.Name, "field returns a connection so it must take first and after, or last and before")
+				}
+			}
+		}
+
+		if pageInfo := schema.Types["PageInfo"]; hasConnections && pageInfo != nil {
+			lintPageInfo(pageInfo, report)
+		}
+	})
+}
+
+// lintConnection checks the fields of a connection, its edges and its page info
+func lintConnection(schema *ast.Schema, connection *ast.Definition, report LintReportFunc) {
+	edges := connection.Fields.ForName("edges")
+	if edges == nil || edges.Type.Elem == nil {
+		report(connection.Name, "connection must have an edges field that returns a list")
+	} else if edge := schema.Types[edges.Type.Name()]; edge != nil {
+		if edge.Kind != ast.Object && edge.Kind != ast.Interface {
+			report(connection.Name+".edges", "edges must be objects, not %s", edge.Kind)
+		} else {
+			if edge.Fields.ForName("node") == nil {
+				report(edge.Name, "edge must have a node field")
+			}
+			if cursor := edge.Fields.ForName("cursor"); cursor == nil || !cursor.Type.NonNull || cursor.Type.Elem != nil {
+				report(edge.Name, "edge must have a non-null cursor field")
+			}
+		}
+	}
+
+	pageInfo := connection.Fields.ForName("pageInfo")
+	if pageInfo == nil || !pageInfo.Type.NonNull || pageInfo.Type.Name() != "PageInfo" {
+		report(connection.Name, "connection must have a pageInfo field of type PageInfo!")
+	}
+}
+
+// lintPageInfo checks the fields of the PageInfo type shared by the connections
+func lintPageInfo(pageInfo *ast.Definition, report LintReportFunc) {
+	for _, name := range []string{"hasNextPage", "hasPreviousPage"} {
+		if field := pageInfo.Fields.ForName(name); field == nil || field.Type.String() != "Boolean!" {
+			report(pageInfo.Name, "page info must have a %s field of type Boolean!", name)
+		}
+	}
+	for _, name := range []string{"startCursor", "endCursor"} {
+		if pageInfo.Fields.ForName(name) == nil {
+			report(pageInfo.Name, "page info must have a %s field", name)
+		}
+	}
+}
+
+// LintNonNullListItems returns a rule that reports lists whose items can be null
+func LintNonNullListItems() LintRule {
+	return NewLintRule("non-null-list-items", LintWarning, func(schema *ast.Schema, report LintReportFunc) {
+		check := func(coordinate string, typ *ast.Type) {
+			for elem := typ; elem != nil; elem = elem.Elem {
+				if elem.Elem != nil && !elem.Elem.NonNull {
+					report(coordinate, "items of %s must be non-null", typ)
+					return
+				}
+			}
+		}
+
+		for _, definition := range lintDefinitions(schema) {
+			for _, field := range lintFields(definition) {
+				coordinate := definition.Name + "." + field.Name
+				check(coordinate, field.Type)
+				for _, arg := range field.Arguments {
+					check(fmt.Sprintf("%s(%s:)", coordinate, arg.Name), arg.Type)
+				}
+			}
+		}
+	})
+}
+
+// LintInputTypeSuffix returns a rule that reports input objects whose name doesn't end with the suffix
+func LintInputTypeSuffix(suffix string) LintRule {
+	return NewLintRule("input-type-suffix", LintError, func(schema *ast.Schema, report LintReportFunc) {
+		for _, definition := range lintDefinitions(schema) {
+			if definition.Kind == ast.InputObject && !strings.HasSuffix(definition.Name, suffix) {
+				report(definition.Name, "input type name must end with %q", suffix)
+			}
+		}
+	})
+}
+
+// lintDefinitions returns the types of the schema that are not built in, sorted by name
+func lintDefinitions(schema *ast.Schema) []*ast.Definition {
+	definitions := []*ast.Definition{}
+	for _, name := range sortedKeys(schema.Types, nil) {
+		if definition := schema.Types[name]; !definition.BuiltIn && !strings.HasPrefix(name, "__") {
+			definitions = append(definitions, definition)
+		}
+	}
+	return definitions
+}
+
+// lintFields returns the fields of a type, leaving out the introspection fields
+func lintFields(definition *ast.Definition) ast.FieldList {
+	fields := ast.FieldList{}
+	for _, field := range definition.Fields {
+		if !strings.HasPrefix(field.Name, "__") {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
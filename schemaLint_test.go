@@ -0,0 +1,182 @@
+package graphql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestLintSchema_rules(t *testing.T) {
+	t.Parallel()
+
+	for _, row := range []struct {
+		Message  string
+		Rule     LintRule
+		Schema   string
+		Problems []string
+	}{
+		{
+			Message: "type names",
+			Rule:    LintTypeNames(),
+			Schema: `
+				type Query { user: user_profile, any: _Any }
+				type user_profile { id: ID }
+				scalar _Any
+			`,
+			Problems: []string{"user_profile"},
+		},
+		{
+			Message: "field names",
+			Rule:    LintFieldNames(),
+			Schema: `
+				type Query { User(First_Name: String, last: Int): String, _service: String }
+				input Filter { Created_at: String }
+			`,
+			Problems: []string{"Filter.Created_at", "Query.User", "Query.User(First_Name:)"},
+		},
+		{
+			Message:  "enum values",
+			Rule:     LintEnumValueNames(),
+			Schema:   `type Query { status: Status } enum Status { ACTIVE, on_hold, BANNED_2 }`,
+			Problems: []string{"Status.on_hold"},
+		},
+		{
+			Message: "descriptions",
+			Rule:    LintDescriptions(),
+			Schema: `
+				"the root" type Query { "a user" user: User, users: [User] }
+				type User { " " name: String }
+			`,
+			Problems: []string{"Query.users", "User", "User.name"},
+		},
+		{
+			Message: "deprecation reasons",
+			Rule:    LintDeprecationReasons(),
+			Schema: `
+				type Query {
+					a: String @deprecated
+					b: String @deprecated(reason: "use c")
+					c(old: Int @deprecated(reason: "No longer supported")): String
+				}
+				enum Status { ACTIVE @deprecated(reason: "") }
+				input Filter { a: Int @deprecated }
+			`,
+			Problems: []string{"Filter.a", "Query.a", "Query.c(old:)", "Status.ACTIVE"},
+		},
+		{
+			Message: "relay connections",
+			Rule:    LintRelayConnections(),
+			Schema: `
+				type Query {
+					users(first: Int, after: String): UserConnection
+					posts(last: Int, before: String): PostConnection!
+					comments(first: Int): CommentConnection
+				}
+				type User { id: ID }
+				type UserConnection { edges: [UserEdge!]!, pageInfo: PageInfo! }
+				type UserEdge { node: User, cursor: String! }
+				type PostConnection { edges: [PostEdge], pageInfo: PageInfo }
+				type PostEdge { node: User, cursor: String }
+				type CommentConnection { nodes: [User] pageInfo: PageInfo! }
+				type PageInfo { hasNextPage: Boolean!, hasPreviousPage: Boolean, startCursor: String }
+			`,
+			Problems: []string{
+				"CommentConnection",
+				"PageInfo",
+				"PageInfo",
+				"PostEdge",
+				"PostConnection",
+				"Query.comments",
+			},
+		},
+		{
+			Message: "non-null list items",
+			Rule:    LintNonNullListItems(),
+			Schema: `
+				type Query { a: [String!]!, b: [String], c(ids: [ID]): [[Int!]] }
+				input Filter { ids: [ID!], names: [String] }
+			`,
+			Problems: []string{"Filter.names", "Query.b", "Query.c", "Query.c(ids:)"},
+		},
+		{
+			Message:  "input type suffix",
+			Rule:     LintInputTypeSuffix("Input"),
+			Schema:   `type Query { a(b: UserInput, c: Filter): String } input UserInput { a: Int } input Filter { a: Int }`,
+			Problems: []string{"Filter"},
+		},
+	} {
+		row := row
+		t.Run(row.Message, func(t *testing.T) {
+			t.Parallel()
+			schema, err := LoadSchema(row.Schema)
+			require.NoError(t, err)
+
+			result := LintSchema(schema, row.Rule)
+			coordinates := []string{}
+			for _, problem := range result {
+				assert.Equal(t, row.Rule.Name(), problem.Rule)
+				assert.Equal(t, row.Rule.Severity(), problem.Severity)
+				coordinates = append(coordinates, problem.Coordinate)
+			}
+			assert.ElementsMatch(t, row.Problems, coordinates)
+		})
+	}
+}
+
+func TestLintSchema_introspection(t *testing.T) {
+	t.Parallel()
+	schema, err := LoadSchema(`
+		type Query {
+			"the users"
+			users(first: Int, after: String): UserConnection!
+			legacy: [user] @deprecated
+		}
+		"a user"
+		type user { id: ID!, Name: String }
+		type UserConnection { edges: [UserEdge]!, pageInfo: PageInfo! }
+		type UserEdge { node: user, cursor: String }
+		type PageInfo { hasNextPage: Boolean!, hasPreviousPage: Boolean!, startCursor: String, endCursor: String }
+		input filter { ids: [ID] }
+		enum Status { active }
+	`)
+	require.NoError(t, err)
+
+	introspected, err := IntrospectAPI(NewSchemaIntrospectionQueryer(schema))
+	require.NoError(t, err)
+
+	// a remote service gets the same problems as the local schema
+	result := LintSchema(schema)
+	assert.NotEmpty(t, result.Filter(LintError))
+	assert.NotEmpty(t, result.Filter(LintWarning))
+	assert.True(t, result.HasErrors())
+	assert.Equal(t, result, LintSchema(introspected))
+}
+
+func TestLintSchema_customRules(t *testing.T) {
+	t.Parallel()
+	schema, err := LoadSchema(`type Query { a: String, b: Int }`)
+	require.NoError(t, err)
+
+	noStrings := NewLintRule("no-strings", LintWarning, func(schema *ast.Schema, report LintReportFunc) {
+		for _, field := range schema.Query.Fields {
+			if field.Type.Name() == "String" {
+				report("Query."+field.Name, "field %s returns a string", field.Name)
+			}
+		}
+	})
+
+	result := LintSchema(schema, noStrings)
+	assert.Equal(t, LintResult{
+		{Rule: "no-strings", Severity: LintWarning, Coordinate: "Query.a", Message: "field a returns a string"},
+	}, result)
+	assert.False(t, result.HasErrors())
+	assert.Equal(t, "WARNING: Query.a: field a returns a string (no-strings)", result[0].String())
+
+	// the severity of any rule can be changed
+	result = LintSchema(schema, LintWithSeverity(noStrings, LintError))
+	require.Len(t, result, 1)
+	assert.Equal(t, LintError, result[0].Severity)
+	assert.True(t, result.HasErrors())
+}
@@ -0,0 +1,417 @@
+package graphql
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// MergedSchema is the combination of several RemoteSchemas
+type MergedSchema struct {
+	Schema *ast.Schema
+	// FieldURLs maps the coordinate of every object and interface field, e.g. User.name, to the URLs of the
+	// services that define it, in the order the schemas were merged
+	FieldURLs map[string][]string
+}
+
+// URLsForField returns the URLs of the services that can resolve the field of the given type
+func (m *MergedSchema) URLsForField(typeName string, fieldName string) []string {
+	return m.FieldURLs[typeName+"."+fieldName]
+}
+
+// MergeConflict describes an element that two services define in incompatible ways
+type MergeConflict struct {
+	// Coordinate is the schema coordinate of the conflicting element, e.g. User.name or Status
+	Coordinate string
+	// URLs holds the service that first defined the element and the one that conflicts with it
+	URLs    []string
+	Message string
+}
+
+func (c *MergeConflict) Error() string {
+	return fmt.Sprintf("%s: %s (%s)", c.Coordinate, c.Message, strings.Join(c.URLs, ", "))
+}
+
+// MergeRemoteSchemas combines the schemas of several services into one. Types with the same name are merged:
+// objects and interfaces get the fields of every service, enums every value, unions every member and
+// input objects only the fields that every service accepts. The root types of each service are merged into Query, Mutation and Subscription.
+// When the schemas disagree, e.g. a field has different types in two services, the first definition is kept
+// and an ErrorList of *MergeConflict is returned along with the merged schema.
+func MergeRemoteSchemas(schemas []*RemoteSchema) (*MergedSchema, error) {
+	// start from the built-in types and directives
+	base, err := gqlparser.LoadSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	m := &schemaMerger{
+		schema:     base,
+		merged:     &MergedSchema{Schema: base, FieldURLs: map[string][]string{}},
+		typeURLs:   map[string]string{},
+		directives: map[string]string{},
+		errs:       ErrorList{},
+	}
+	for _, remote := range schemas {
+		if remote.Schema == nil {
+			m.errs = append(m.errs, &RemoteSchemaError{URL: remote.URL, Err: errors.New("schema is missing")})
+			continue
+		}
+		m.mergeSchema(remote)
+	}
+	m.finish()
+
+	if len(m.errs) > 0 {
+		return m.merged, m.errs
+	}
+	return m.merged, nil
+}
+
+// schemaMerger accumulates the merged schema and the conflicts found along the way
+type schemaMerger struct {
+	schema *ast.Schema
+	merged *MergedSchema
+	// typeURLs and directives hold the URL of the service that first defined each type and directive
+	typeURLs   map[string]string
+	directives map[string]string
+	errs       ErrorList
+}
+
+func (m *schemaMerger) conflict(coordinate string, urls []string, format string, args ...interface{}) {
+	m.errs = append(m.errs, &MergeConflict{
+		Coordinate: coordinate,
+		URLs:       urls,
+		Message:    fmt.Sprintf(format, args...),
+	})
+}
+
+func (m *schemaMerger) mergeSchema(remote *RemoteSchema) {
+	// root types are merged by operation, whatever the service calls them
+	rootNames := map[string]string{}
+	for _, root := range []struct {
+		definition *ast.Definition
+		name       string
+	}{
+		{remote.Schema.Query, "Query"},
+		{remote.Schema.Mutation, "Mutation"},
+		{remote.Schema.Subscription, "Subscription"},
+	} {
+		if root.definition != nil && root.definition.Name != root.name {
+			rootNames[root.definition.Name] = root.name
+		}
+	}
+
+	for _, name := range sortedKeys(remote.Schema.Types, nil) {
+		definition := remote.Schema.Types[name]
+		if definition.BuiltIn {
+			continue
+		}
+		// the types that refer to a renamed root type have to follow it
+		if len(rootNames) > 0 {
+			definition = renameTypeReferences(definition, rootNames)
+		}
+		m.mergeDefinition(remote.URL, definition)
+	}
+
+	for _, name := range sortedKeys(remote.Schema.Directives, nil) {
+		directive := remote.Schema.Directives[name]
+		if !isBuiltInDirective(directive) {
+			m.mergeDirective(remote.URL, directive)
+		}
+	}
+}
+
+func (m *schemaMerger) mergeDefinition(url string, definition *ast.Definition) {
+	existing, ok := m.schema.Types[definition.Name]
+	if !ok {
+		// copy the lists we add to so the remote schemas are left alone
+		merged := *definition
+		merged.Fields = ast.FieldList{}
+		merged.EnumValues = append(ast.EnumValueList{}, definition.EnumValues...)
+		merged.Interfaces = append([]string{}, definition.Interfaces...)
+		merged.Types = append([]string{}, definition.Types...)
+		for _, field := range definition.Fields {
+			if !strings.HasPrefix(field.Name, "__") {
+				merged.Fields = append(merged.Fields, field)
+				m.addFieldURL(&merged, field, url)
+			}
+		}
+
+		m.schema.Types[merged.Name] = &merged
+		m.typeURLs[merged.Name] = url
+		return
+	}
+
+	owner := m.typeURLs[definition.Name]
+	if existing.Kind != definition.Kind {
+		m.conflict(definition.Name, []string{owner, url}, "type is a %s in one service and a %s in the other", existing.Kind, definition.Kind)
+		return
+	}
+	if existing.Description == "" {
+		existing.Description = definition.Description
+	}
+
+	switch definition.Kind {
+	case ast.InputObject:
+		m.mergeInputFields(url, existing, definition)
+	case ast.Object, ast.Interface:
+		for _, name := range definition.Interfaces {
+			if !containsString(existing.Interfaces, name) {
+				existing.Interfaces = append(existing.Interfaces, name)
+			}
+		}
+		for _, field := range definition.Fields {
+			if !strings.HasPrefix(field.Name, "__") {
+				m.mergeField(url, existing, field)
+			}
+		}
+	case ast.Enum:
+		for _, value := range definition.EnumValues {
+			if existing.EnumValues.ForName(value.Name) == nil {
+				existing.EnumValues = append(existing.EnumValues, value)
+			}
+		}
+	case ast.Union:
+		for _, name := range definition.Types {
+			if !containsString(existing.Types, name) {
+				existing.Types = append(existing.Types, name)
+			}
+		}
+	}
+}
+
+func (m *schemaMerger) mergeField(url string, definition *ast.Definition, field *ast.FieldDefinition) {
+	coordinate := definition.Name + "." + field.Name
+
+	existing := definition.Fields.ForName(field.Name)
+	if existing == nil {
+		definition.Fields = append(definition.Fields, field)
+		m.addFieldURL(definition, field, url)
+		return
+	}
+
+	// the first service that defined the field is the one to compare against
+	owner := m.typeURLs[definition.Name]
+	if urls := m.merged.FieldURLs[coordinate]; len(urls) > 0 {
+		owner = urls[0]
+	}
+
+	if existing.Type.String() != field.Type.String() {
+		m.conflict(coordinate, []string{owner, url}, "field has type %s in one service and %s in the other", existing.Type, field.Type)
+		return
+	}
+	if defaultValueString(existing.DefaultValue) != defaultValueString(field.DefaultValue) {
+		m.conflict(coordinate, []string{owner, url}, "field has default value %s in one service and %s in the other", defaultValueString(existing.DefaultValue), defaultValueString(field.DefaultValue))
+		return
+	}
+	if conflict := argumentsConflict(existing.Arguments, field.Arguments); conflict != "" {
+		m.conflict(coordinate, []string{owner, url}, "%s", conflict)
+		return
+	}
+
+	m.addFieldURL(definition, field, url)
+}
+
+// mergeInputFields keeps the fields of an input object that every service accepts, since the gateway forwards
+// the same input to all of them. A required field that some service doesn't know about is a conflict.
+func (m *schemaMerger) mergeInputFields(url string, existing *ast.Definition, definition *ast.Definition) {
+	owner := m.typeURLs[definition.Name]
+
+	fields := ast.FieldList{}
+	for _, field := range existing.Fields {
+		other := definition.Fields.ForName(field.Name)
+		if other == nil {
+			if isRequiredInputField(field) {
+				m.conflict(definition.Name+"."+field.Name, []string{owner, url}, "input field is required in one service and missing from the other")
+			}
+			continue
+		}
+
+		coordinate := definition.Name + "." + field.Name
+		if field.Type.String() != other.Type.String() {
+			m.conflict(coordinate, []string{owner, url}, "field has type %s in one service and %s in the other", field.Type, other.Type)
+		} else if defaultValueString(field.DefaultValue) != defaultValueString(other.DefaultValue) {
+			m.conflict(coordinate, []string{owner, url}, "field has default value %s in one service and %s in the other", defaultValueString(field.DefaultValue), defaultValueString(other.DefaultValue))
+		}
+		fields = append(fields, field)
+	}
+	for _, field := range definition.Fields {
+		if existing.Fields.ForName(field.Name) == nil && isRequiredInputField(field) {
+			m.conflict(definition.Name+"."+field.Name, []string{owner, url}, "input field is required in one service and missing from the other")
+		}
+	}
+
+	if len(fields) == 0 {
+		m.conflict(definition.Name, []string{owner, url}, "input object has no field in common between the services")
+		return
+	}
+	existing.Fields = fields
+}
+
+// isRequiredInputField returns true if the input field must be provided
+func isRequiredInputField(field *ast.FieldDefinition) bool {
+	return field.Type.NonNull && field.DefaultValue == nil
+}
+
+// renameTypeReferences returns a copy of the definition where the types in names are renamed,
+// both the definition itself and the types its fields, members and interfaces refer to
+func renameTypeReferences(definition *ast.Definition, names map[string]string) *ast.Definition {
+	renamed := *definition
+	if name, ok := names[definition.Name]; ok {
+		renamed.Name = name
+	}
+
+	renamed.Fields = make(ast.FieldList, 0, len(definition.Fields))
+	for _, field := range definition.Fields {
+		copied := *field
+		copied.Type = renameTypeReference(field.Type, names)
+		renamed.Fields = append(renamed.Fields, &copied)
+	}
+
+	renamed.Types = make([]string, 0, len(definition.Types))
+	for _, member := range definition.Types {
+		if name, ok := names[member]; ok {
+			member = name
+		}
+		renamed.Types = append(renamed.Types, member)
+	}
+
+	renamed.Interfaces = make([]string, 0, len(definition.Interfaces))
+	for _, iface := range definition.Interfaces {
+		if name, ok := names[iface]; ok {
+			iface = name
+		}
+		renamed.Interfaces = append(renamed.Interfaces, iface)
+	}
+
+	return &renamed
+}
+
+func renameTypeReference(t *ast.Type, names map[string]string) *ast.Type {
+	if t == nil {
+		return nil
+	}
+	copied := *t
+	if name, ok := names[t.NamedType]; ok {
+		copied.NamedType = name
+	}
+	copied.Elem = renameTypeReference(t.Elem, names)
+	return &copied
+}
+
+// addFieldURL records that the service at url can resolve the field
+func (m *schemaMerger) addFieldURL(definition *ast.Definition, field *ast.FieldDefinition, url string) {
+	if definition.Kind != ast.Object && definition.Kind != ast.Interface {
+		return
+	}
+	coordinate := definition.Name + "." + field.Name
+	if !containsString(m.merged.FieldURLs[coordinate], url) {
+		m.merged.FieldURLs[coordinate] = append(m.merged.FieldURLs[coordinate], url)
+	}
+}
+
+func (m *schemaMerger) mergeDirective(url string, directive *ast.DirectiveDefinition) {
+	coordinate := "@" + directive.Name
+
+	existing, ok := m.schema.Directives[directive.Name]
+	if !ok {
+		m.schema.Directives[directive.Name] = directive
+		m.directives[directive.Name] = url
+		return
+	}
+
+	if conflict := argumentsConflict(existing.Arguments, directive.Arguments); conflict != "" {
+		m.conflict(coordinate, []string{m.directives[directive.Name], url}, "%s", conflict)
+		return
+	}
+
+	// a directive can be used wherever one of the services allows it
+	locations := existing.Locations
+	for _, location := range directive.Locations {
+		if !containsLocation(locations, location) {
+			locations = append(append([]ast.DirectiveLocation{}, locations...), location)
+		}
+	}
+	if len(locations) != len(existing.Locations) {
+		merged := *existing
+		merged.Locations = locations
+		m.schema.Directives[directive.Name] = &merged
+	}
+}
+
+// argumentsConflict describes the difference between two argument lists, or returns an empty string if they match
+func argumentsConflict(a, b ast.ArgumentDefinitionList) string {
+	for _, argA := range a {
+		argB := b.ForName(argA.Name)
+		if argB == nil {
+			return fmt.Sprintf("argument %s is only defined in one service", argA.Name)
+		}
+		if argA.Type.String() != argB.Type.String() {
+			return fmt.Sprintf("argument %s has type %s in one service and %s in the other", argA.Name, argA.Type, argB.Type)
+		}
+		if defaultValueString(argA.DefaultValue) != defaultValueString(argB.DefaultValue) {
+			return fmt.Sprintf("argument %s has default value %s in one service and %s in the other", argA.Name, defaultValueString(argA.DefaultValue), defaultValueString(argB.DefaultValue))
+		}
+	}
+	for _, argB := range b {
+		if a.ForName(argB.Name) == nil {
+			return fmt.Sprintf("argument %s is only defined in one service", argB.Name)
+		}
+	}
+	return ""
+}
+
+// finish sets the root types and the relationships between abstract types and their members
+func (m *schemaMerger) finish() {
+	m.schema.Query = m.schema.Types["Query"]
+	m.schema.Mutation = m.schema.Types["Mutation"]
+	m.schema.Subscription = m.schema.Types["Subscription"]
+
+	schemaLinkPossibleTypes(m.schema, func(definition *ast.Definition, err error) {
+		m.conflict(definition.Name, []string{m.typeURLs[definition.Name]}, "%s", err.Error())
+	})
+
+	// like gqlparser, expose the introspection fields on the query type
+	if m.schema.Query != nil {
+		m.schema.Query.Fields = append(m.schema.Query.Fields,
+			&ast.FieldDefinition{
+				Name: "__schema",
+				Type: ast.NonNullNamedType("__Schema", nil),
+			},
+			&ast.FieldDefinition{
+				Name: "__type",
+				Type: ast.NamedType("__Type", nil),
+				Arguments: ast.ArgumentDefinitionList{
+					{Name: "name", Type: ast.NonNullNamedType("String", nil)},
+				},
+			},
+		)
+	}
+}
+
+// schemaLinkPossibleTypes records the members of every union and interface in the schema's PossibleTypes and
+// Implements, calling onError for the definitions that implement something that is not an interface
+func schemaLinkPossibleTypes(schema *ast.Schema, onError func(definition *ast.Definition, err error)) {
+	for _, name := range sortedKeys(schema.Types, nil) {
+		definition := schema.Types[name]
+
+		switch definition.Kind {
+		case ast.Object:
+			addPossibleTypeOnce(schema, definition.Name, definition)
+			fallthrough
+		case ast.Interface:
+			if err := introspectionAddInterfaceAncestors(schema, definition); err != nil {
+				onError(definition, err)
+			}
+		case ast.Union:
+			for _, member := range definition.Types {
+				if memberDefinition, ok := schema.Types[member]; ok {
+					addPossibleTypeOnce(schema, definition.Name, memberDefinition)
+					addImplementsOnce(schema, member, definition)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package graphql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mergeTestRemoteSchemas(t *testing.T, sources map[string]string, urls ...string) []*RemoteSchema {
+	t.Helper()
+	schemas := []*RemoteSchema{}
+	for _, url := range urls {
+		schema, err := LoadSchema(sources[url])
+		require.NoError(t, err)
+		schemas = append(schemas, &RemoteSchema{URL: url, Schema: schema})
+	}
+	return schemas
+}
+
+func TestMergeRemoteSchemas(t *testing.T) {
+	t.Parallel()
+	schemas := mergeTestRemoteSchemas(t, map[string]string{
+		"users": `
+			type Query {
+				node(id: ID!): Node
+				me: User
+			}
+
+			interface Node {
+				id: ID!
+			}
+
+			type User implements Node {
+				id: ID!
+				name: String
+			}
+
+			enum Role {
+				ADMIN
+			}
+
+			union Actor = User
+
+			input UserFilter {
+				name: String
+			}
+
+			directive @cached(ttl: Int) on FIELD_DEFINITION
+		`,
+		"posts": `
+			schema {
+				query: PostQuery
+				mutation: PostMutation
+			}
+
+			type PostQuery {
+				node(id: ID!): Node
+				posts(filter: UserFilter): [Post!]!
+			}
+
+			type PostMutation {
+				publish(id: ID!): Post
+			}
+
+			interface Node {
+				id: ID!
+			}
+
+			type User implements Node {
+				id: ID!
+				posts: [Post!]!
+			}
+
+			type Post implements Node {
+				id: ID!
+				author: User
+				status: Role
+			}
+
+			enum Role {
+				ADMIN
+				AUTHOR
+			}
+
+			union Actor = Post
+
+			input UserFilter {
+				name: String
+				role: Role
+			}
+
+			directive @cached(ttl: Int) on OBJECT
+		`,
+	}, "users", "posts")
+
+	merged, err := MergeRemoteSchemas(schemas)
+	require.NoError(t, err)
+	schema := merged.Schema
+
+	// the root types are merged by operation
+	require.NotNil(t, schema.Query)
+	assert.Equal(t, "Query", schema.Query.Name)
+	assert.Nil(t, schema.Types["PostQuery"])
+	require.NotNil(t, schema.Mutation)
+	assert.Equal(t, "Mutation", schema.Mutation.Name)
+	assert.NotNil(t, schema.Mutation.Fields.ForName("publish"))
+
+	// every service owns the fields it defines
+	assert.Equal(t, []string{"users", "posts"}, merged.URLsForField("Query", "node"))
+	assert.Equal(t, []string{"users"}, merged.URLsForField("Query", "me"))
+	assert.Equal(t, []string{"posts"}, merged.URLsForField("Query", "posts"))
+	assert.Equal(t, []string{"posts"}, merged.URLsForField("Mutation", "publish"))
+	assert.Equal(t, []string{"users", "posts"}, merged.URLsForField("User", "id"))
+	assert.Equal(t, []string{"users"}, merged.URLsForField("User", "name"))
+	assert.Equal(t, []string{"posts"}, merged.URLsForField("User", "posts"))
+	assert.Equal(t, []string{"users", "posts"}, merged.URLsForField("Node", "id"))
+
+	// fields, values and members are combined
+	assert.Len(t, schema.Types["User"].Fields, 3)
+	assert.Len(t, schema.Types["Role"].EnumValues, 2)
+	assert.Equal(t, []string{"User", "Post"}, schema.Types["Actor"].Types)
+	// but input objects only keep what every service accepts
+	require.Len(t, schema.Types["UserFilter"].Fields, 1)
+	assert.Equal(t, "name", schema.Types["UserFilter"].Fields[0].Name)
+	assert.Len(t, schema.Directives["cached"].Locations, 2)
+
+	// abstract types know their members
+	assert.Len(t, schema.GetPossibleTypes(schema.Types["Node"]), 2)
+	assert.Len(t, schema.GetPossibleTypes(schema.Types["Actor"]), 2)
+
+	// the result is a valid schema that can be printed
+	assert.NoError(t, ValidateSchema(schema))
+	printed, err := PrintSchema(schema)
+	require.NoError(t, err)
+	_, err = LoadSchema(printed)
+	assert.NoError(t, err)
+
+	// the remote schemas are left alone
+	assert.Len(t, schemas[0].Schema.Types["User"].Fields, 2)
+	assert.Len(t, schemas[0].Schema.Types["Role"].EnumValues, 1)
+}
+
+func TestMergeRemoteSchemas_conflicts(t *testing.T) {
+	t.Parallel()
+	schemas := mergeTestRemoteSchemas(t, map[string]string{
+		"a": `
+			type Query { user: User, search(term: String): [String] }
+			type User { id: ID!, age: Int }
+			enum Status { ON }
+			directive @cached(ttl: Int) on FIELD_DEFINITION
+		`,
+		"b": `
+			type Query { user: User, search(term: String!): [String] }
+			type User { id: String!, age: Int }
+			scalar Status
+			directive @cached(maxAge: Int) on FIELD_DEFINITION
+		`,
+	}, "a", "b")
+
+	merged, err := MergeRemoteSchemas(schemas)
+	require.Error(t, err)
+
+	conflicts := []MergeConflict{}
+	for _, err := range err.(ErrorList) {
+		conflicts = append(conflicts, *err.(*MergeConflict))
+	}
+	assert.Equal(t, []MergeConflict{
+		{Coordinate: "Query.search", URLs: []string{"a", "b"}, Message: "argument term has type String in one service and String! in the other"},
+		{Coordinate: "Status", URLs: []string{"a", "b"}, Message: "type is a ENUM in one service and a SCALAR in the other"},
+		{Coordinate: "User.id", URLs: []string{"a", "b"}, Message: "field has type ID! in one service and String! in the other"},
+		{Coordinate: "@cached", URLs: []string{"a", "b"}, Message: "argument ttl is only defined in one service"},
+	}, conflicts)
+
+	// the first definition wins
+	require.NotNil(t, merged)
+	assert.Equal(t, "ID!", merged.Schema.Types["User"].Fields.ForName("id").Type.String())
+	assert.Equal(t, []string{"a"}, merged.URLsForField("User", "id"))
+	assert.Equal(t, []string{"a", "b"}, merged.URLsForField("User", "age"))
+}
+
+func TestMergeRemoteSchemas_inputObjects(t *testing.T) {
+	t.Parallel()
+	schemas := mergeTestRemoteSchemas(t, map[string]string{
+		"a": `
+			type Query { search(filter: Filter, page: Page): [String] }
+			input Filter { term: String, limit: Int! }
+			input Page { first: Int }
+		`,
+		"b": `
+			type Query { search(filter: Filter, page: Page): [String] }
+			input Filter { term: String, tags: [String], offset: Int! = 0 }
+			input Page { after: String }
+		`,
+	}, "a", "b")
+
+	merged, err := MergeRemoteSchemas(schemas)
+	require.Error(t, err)
+
+	conflicts := []MergeConflict{}
+	for _, err := range err.(ErrorList) {
+		conflicts = append(conflicts, *err.(*MergeConflict))
+	}
+	assert.Equal(t, []MergeConflict{
+		{Coordinate: "Filter.limit", URLs: []string{"a", "b"}, Message: "input field is required in one service and missing from the other"},
+		{Coordinate: "Page", URLs: []string{"a", "b"}, Message: "input object has no field in common between the services"},
+	}, conflicts)
+
+	// optional fields that only one service knows about are dropped
+	filter := merged.Schema.Types["Filter"]
+	require.Len(t, filter.Fields, 1)
+	assert.Equal(t, "term", filter.Fields[0].Name)
+}
+
+func TestMergeRemoteSchemas_renamedRootReferences(t *testing.T) {
+	t.Parallel()
+	schemas := mergeTestRemoteSchemas(t, map[string]string{
+		"a": `
+			schema { query: RootQuery, mutation: RootMutation }
+			type RootQuery { viewer: RootQuery, me: User, results: [Result!]! }
+			type RootMutation { logout: RootQuery }
+			type User { name: String }
+			union Result = User | RootQuery
+		`,
+		"b": `type Query { hello: String }`,
+	}, "a", "b")
+
+	merged, err := MergeRemoteSchemas(schemas)
+	require.NoError(t, err)
+	schema := merged.Schema
+
+	// nothing refers to the names the service gave its root types
+	assert.Nil(t, schema.Types["RootQuery"])
+	assert.Nil(t, schema.Types["RootMutation"])
+	assert.Equal(t, "Query", schema.Query.Fields.ForName("viewer").Type.Name())
+	assert.Equal(t, "Query", schema.Mutation.Fields.ForName("logout").Type.Name())
+	assert.Equal(t, []string{"User", "Query"}, schema.Types["Result"].Types)
+	assert.NotNil(t, schema.Query.Fields.ForName("hello"))
+	assert.NoError(t, ValidateSchema(schema))
+
+	// the remote schema is left alone
+	assert.Equal(t, "RootQuery", schemas[0].Schema.Types["RootQuery"].Fields.ForName("viewer").Type.Name())
+}
+
+func TestMergeRemoteSchemas_missingSchema(t *testing.T) {
+	t.Parallel()
+	schemas := mergeTestRemoteSchemas(t, map[string]string{"a": `type Query { a: String }`}, "a")
+	schemas = append(schemas, &RemoteSchema{URL: "b"})
+
+	merged, err := MergeRemoteSchemas(schemas)
+	assert.EqualError(t, err, "could not introspect b: schema is missing")
+	assert.NotNil(t, merged.Schema.Query.Fields.ForName("a"))
+}
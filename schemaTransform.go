@@ -0,0 +1,596 @@
+package graphql
+
+import (
+	"fmt"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// SchemaTransform changes a schema, e.g. to namespace or hide the types of a third-party service
+type SchemaTransform interface {
+	// Transform returns a transformed copy of the schema, leaving the original alone, along with the
+	// QueryRewriter that translates between the two
+	Transform(schema *ast.Schema) (*ast.Schema, QueryRewriter, error)
+}
+
+// SchemaTransformFunc lets a function act as a SchemaTransform
+type SchemaTransformFunc func(schema *ast.Schema) (*ast.Schema, QueryRewriter, error)
+
+// Transform invokes the function
+func (fn SchemaTransformFunc) Transform(schema *ast.Schema) (*ast.Schema, QueryRewriter, error) {
+	return fn(schema)
+}
+
+// QueryRewriter translates the queries written against a transformed schema and their responses
+type QueryRewriter interface {
+	// RewriteQuery returns a copy of a document written against the transformed schema that can be sent to
+	// the service with the original schema
+	RewriteQuery(document *ast.QueryDocument) (*ast.QueryDocument, error)
+	// RewriteResponse updates, in place, the data the original service returned for the operation of the
+	// document so it matches the transformed schema. The document is the one written against the transformed schema.
+	RewriteResponse(document *ast.QueryDocument, operationName string, data map[string]interface{}) (map[string]interface{}, error)
+}
+
+// TransformedSchema is the result of applying a series of SchemaTransforms to a schema. It is a QueryRewriter
+// that undoes all of them, so it can be used as a SchemaTransform's rewriter itself.
+type TransformedSchema struct {
+	Original *ast.Schema
+	Schema   *ast.Schema
+
+	rewriters []QueryRewriter
+}
+
+// TransformSchema applies the transforms to the schema in order
+func TransformSchema(schema *ast.Schema, transforms ...SchemaTransform) (*TransformedSchema, error) {
+	result := &TransformedSchema{Original: schema, Schema: schema}
+	for _, transform := range transforms {
+		transformed, rewriter, err := transform.Transform(result.Schema)
+		if err != nil {
+			return nil, err
+		}
+		result.Schema = transformed
+		result.rewriters = append(result.rewriters, rewriter)
+	}
+	return result, nil
+}
+
+// RewriteQuery translates a document written against the transformed schema into one for the original schema
+func (t *TransformedSchema) RewriteQuery(document *ast.QueryDocument) (*ast.QueryDocument, error) {
+	documents, err := t.rewriteQueries(document)
+	if err != nil {
+		return nil, err
+	}
+	return documents[0], nil
+}
+
+// RewriteResponse translates the data of a response from the original schema into the shape of the transformed
+// schema. The document is the one written against the transformed schema.
+func (t *TransformedSchema) RewriteResponse(document *ast.QueryDocument, operationName string, data map[string]interface{}) (map[string]interface{}, error) {
+	documents, err := t.rewriteQueries(document)
+	if err != nil {
+		return nil, err
+	}
+
+	// the responses go through the transforms in the order they were applied, each with the document it produced
+	for i, rewriter := range t.rewriters {
+		data, err = rewriter.RewriteResponse(documents[i+1], operationName, data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// rewriteQueries returns the document as it looks before each transform, starting with the original schema's
+func (t *TransformedSchema) rewriteQueries(document *ast.QueryDocument) ([]*ast.QueryDocument, error) {
+	documents := make([]*ast.QueryDocument, len(t.rewriters)+1)
+	documents[len(t.rewriters)] = document
+	for i := len(t.rewriters) - 1; i >= 0; i-- {
+		rewritten, err := t.rewriters[i].RewriteQuery(documents[i+1])
+		if err != nil {
+			return nil, err
+		}
+		documents[i] = rewritten
+	}
+	return documents, nil
+}
+
+// RenameType returns a SchemaTransform that renames a type and every reference to it
+func RenameType(from string, to string) SchemaTransform {
+	return SchemaTransformFunc(func(schema *ast.Schema) (*ast.Schema, QueryRewriter, error) {
+		return renameTypes(schema, map[string]string{from: to})
+	})
+}
+
+// PrefixTypes returns a SchemaTransform that adds the prefix to the name of every type except the root
+// types and the built-in ones
+func PrefixTypes(prefix string) SchemaTransform {
+	return SchemaTransformFunc(func(schema *ast.Schema) (*ast.Schema, QueryRewriter, error) {
+		names := map[string]string{}
+		for name, definition := range schema.Types {
+			if !definition.BuiltIn && definition != schema.Query && definition != schema.Mutation && definition != schema.Subscription {
+				names[name] = prefix + name
+			}
+		}
+		return renameTypes(schema, names)
+	})
+}
+
+// RemoveField returns a SchemaTransform that hides a field of an object, interface or input object
+func RemoveField(typeName string, fieldName string) SchemaTransform {
+	return SchemaTransformFunc(func(schema *ast.Schema) (*ast.Schema, QueryRewriter, error) {
+		definition, ok := schema.Types[typeName]
+		if !ok || definition.BuiltIn {
+			return nil, nil, fmt.Errorf("cannot remove field %s.%s: type %s is not defined", typeName, fieldName, typeName)
+		}
+		if definition.Fields.ForName(fieldName) == nil {
+			return nil, nil, fmt.Errorf("cannot remove field %s.%s: field is not defined", typeName, fieldName)
+		}
+
+		transformed := copySchema(schema)
+		removed := transformed.Types[typeName]
+		removed.Fields = removeFieldDefinition(removed.Fields, fieldName)
+		return transformed, identityQueryRewriter{}, nil
+	})
+}
+
+// RemoveTypes returns a SchemaTransform that hides every type matching the predicate, along with the fields and
+// arguments that refer to them. Types left without any fields are removed too. Built-in types are never removed.
+func RemoveTypes(predicate func(definition *ast.Definition) bool) SchemaTransform {
+	return SchemaTransformFunc(func(schema *ast.Schema) (*ast.Schema, QueryRewriter, error) {
+		removed := map[string]bool{}
+		for name, definition := range schema.Types {
+			if !definition.BuiltIn && predicate(definition) {
+				removed[name] = true
+			}
+		}
+
+		transformed, err := removeTypes(schema, removed)
+		return transformed, identityQueryRewriter{}, err
+	})
+}
+
+// PruneUnreachableTypes returns a SchemaTransform that removes the types that cannot be reached from the root
+// types or the arguments of a directive
+func PruneUnreachableTypes() SchemaTransform {
+	return SchemaTransformFunc(func(schema *ast.Schema) (*ast.Schema, QueryRewriter, error) {
+		reachable := map[string]bool{}
+		var visit func(name string)
+		visit = func(name string) {
+			definition, ok := schema.Types[name]
+			if !ok || reachable[name] {
+				return
+			}
+			reachable[name] = true
+
+			for _, field := range definition.Fields {
+				visit(field.Type.Name())
+				for _, arg := range field.Arguments {
+					visit(arg.Type.Name())
+				}
+			}
+			for _, iface := range definition.Interfaces {
+				visit(iface)
+			}
+			for _, member := range definition.Types {
+				visit(member)
+			}
+			// queries can select the implementations of an interface with fragments
+			for _, possibleType := range schema.PossibleTypes[name] {
+				visit(possibleType.Name)
+			}
+		}
+
+		for _, root := range []*ast.Definition{schema.Query, schema.Mutation, schema.Subscription} {
+			if root != nil {
+				visit(root.Name)
+			}
+		}
+		for _, directive := range schema.Directives {
+			for _, arg := range directive.Arguments {
+				visit(arg.Type.Name())
+			}
+		}
+
+		removed := map[string]bool{}
+		for name, definition := range schema.Types {
+			if !definition.BuiltIn && !reachable[name] {
+				removed[name] = true
+			}
+		}
+
+		transformed, err := removeTypes(schema, removed)
+		return transformed, identityQueryRewriter{}, err
+	})
+}
+
+// identityQueryRewriter is the rewriter for transforms that only remove parts of a schema, since a query
+// written against what's left is also valid for the original
+type identityQueryRewriter struct{}
+
+func (identityQueryRewriter) RewriteQuery(document *ast.QueryDocument) (*ast.QueryDocument, error) {
+	return document, nil
+}
+
+func (identityQueryRewriter) RewriteResponse(document *ast.QueryDocument, operationName string, data map[string]interface{}) (map[string]interface{}, error) {
+	return data, nil
+}
+
+// renameTypes returns a copy of the schema with the types renamed according to names
+func renameTypes(schema *ast.Schema, names map[string]string) (*ast.Schema, QueryRewriter, error) {
+	original := map[string]string{}
+	for from, to := range names {
+		definition, ok := schema.Types[from]
+		if !ok || definition.BuiltIn {
+			return nil, nil, fmt.Errorf("cannot rename type %s: type is not defined", from)
+		}
+		if _, taken := schema.Types[to]; taken && names[to] == "" {
+			return nil, nil, fmt.Errorf("cannot rename type %s: %s is already defined", from, to)
+		}
+		if _, taken := original[to]; taken {
+			return nil, nil, fmt.Errorf("cannot rename type %s: another type is also renamed to %s", from, to)
+		}
+		original[to] = from
+	}
+
+	rename := func(name string) string {
+		if renamed, ok := names[name]; ok {
+			return renamed
+		}
+		return name
+	}
+
+	transformed := copySchema(schema)
+	transformed.Types = map[string]*ast.Definition{}
+	for name, definition := range schema.Types {
+		copied := copyDefinition(definition)
+		if !definition.BuiltIn {
+			copied.Name = rename(name)
+			renameDefinitionTypes(copied, rename)
+		}
+		transformed.Types[copied.Name] = copied
+	}
+	for _, directive := range transformed.Directives {
+		for _, arg := range directive.Arguments {
+			renameType(arg.Type, rename)
+		}
+	}
+	linkTransformedSchema(schema, transformed, rename)
+
+	return transformed, &typeRenameRewriter{names: names, original: original}, nil
+}
+
+func renameDefinitionTypes(definition *ast.Definition, rename func(string) string) {
+	for _, field := range definition.Fields {
+		renameType(field.Type, rename)
+		for _, arg := range field.Arguments {
+			renameType(arg.Type, rename)
+		}
+	}
+	for i, name := range definition.Interfaces {
+		definition.Interfaces[i] = rename(name)
+	}
+	for i, name := range definition.Types {
+		definition.Types[i] = rename(name)
+	}
+}
+
+func renameType(typ *ast.Type, rename func(string) string) {
+	if typ.Elem != nil {
+		renameType(typ.Elem, rename)
+		return
+	}
+	typ.NamedType = rename(typ.NamedType)
+}
+
+// typeRenameRewriter maps the type names used in queries back to the original ones, and the type names in
+// responses forward to the new ones
+type typeRenameRewriter struct {
+	// names maps the original names to the new ones and original maps them back
+	names    map[string]string
+	original map[string]string
+}
+
+func (r *typeRenameRewriter) RewriteQuery(document *ast.QueryDocument) (*ast.QueryDocument, error) {
+	return copyQueryDocument(document, func(name string) string {
+		if from, ok := r.original[name]; ok {
+			return from
+		}
+		return name
+	}), nil
+}
+
+func (r *typeRenameRewriter) RewriteResponse(document *ast.QueryDocument, operationName string, data map[string]interface{}) (map[string]interface{}, error) {
+	operation := document.Operations.ForName(operationName)
+	if operation == nil {
+		return nil, fmt.Errorf("could not find operation %q", operationName)
+	}
+
+	r.rewriteObject(document, operation.SelectionSet, data)
+	return data, nil
+}
+
+func (r *typeRenameRewriter) rewriteObject(document *ast.QueryDocument, selectionSet ast.SelectionSet, object map[string]interface{}) {
+	for _, selection := range selectionSet {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			key := selection.Alias
+			if key == "" {
+				key = selection.Name
+			}
+
+			if selection.Name == "__typename" {
+				if typename, ok := object[key].(string); ok {
+					if renamed, ok := r.names[typename]; ok {
+						object[key] = renamed
+					}
+				}
+				continue
+			}
+			r.rewriteValue(document, selection.SelectionSet, object[key])
+
+		case *ast.InlineFragment:
+			r.rewriteObject(document, selection.SelectionSet, object)
+
+		case *ast.FragmentSpread:
+			if fragment := document.Fragments.ForName(selection.Name); fragment != nil {
+				r.rewriteObject(document, fragment.SelectionSet, object)
+			}
+		}
+	}
+}
+
+func (r *typeRenameRewriter) rewriteValue(document *ast.QueryDocument, selectionSet ast.SelectionSet, value interface{}) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		r.rewriteObject(document, selectionSet, value)
+	case []interface{}:
+		for _, item := range value {
+			r.rewriteValue(document, selectionSet, item)
+		}
+	}
+}
+
+// removeTypes returns a copy of the schema without the removed types, the fields and arguments that use them,
+// and the types that are left empty as a result
+func removeTypes(schema *ast.Schema, removed map[string]bool) (*ast.Schema, error) {
+	transformed := copySchema(schema)
+
+	for changed := true; changed; {
+		changed = false
+		for name, definition := range transformed.Types {
+			if removed[name] || definition.BuiltIn {
+				continue
+			}
+
+			fields := ast.FieldList{}
+			for _, field := range definition.Fields {
+				if removed[field.Type.Name()] {
+					// a required input field can't be left out, so the input object goes too
+					if definition.Kind == ast.InputObject && field.Type.NonNull && field.DefaultValue == nil {
+						removed[name] = true
+					}
+					continue
+				}
+
+				args, ok := removeArguments(field.Arguments, removed)
+				if !ok {
+					continue
+				}
+				field.Arguments = args
+				fields = append(fields, field)
+			}
+			definition.Fields = fields
+
+			definition.Interfaces = removeNames(definition.Interfaces, removed)
+			definition.Types = removeNames(definition.Types, removed)
+
+			// a type without any fields or members is not valid
+			switch {
+			case (definition.Kind == ast.Object || definition.Kind == ast.Interface || definition.Kind == ast.InputObject) && len(definition.Fields) == 0,
+				definition.Kind == ast.Union && len(definition.Types) == 0:
+				removed[name] = true
+			}
+			if removed[name] {
+				changed = true
+			}
+		}
+	}
+
+	for _, root := range []*ast.Definition{schema.Query, schema.Mutation, schema.Subscription} {
+		if root != nil && removed[root.Name] {
+			return nil, fmt.Errorf("cannot remove root type %s", root.Name)
+		}
+	}
+
+	for name := range removed {
+		delete(transformed.Types, name)
+	}
+	for name, directive := range transformed.Directives {
+		args, ok := removeArguments(directive.Arguments, removed)
+		if !ok {
+			delete(transformed.Directives, name)
+			continue
+		}
+		directive.Arguments = args
+	}
+	linkTransformedSchema(schema, transformed, keepName)
+
+	return transformed, nil
+}
+
+// removeArguments drops the arguments of a removed type. It returns false if one of them is required.
+func removeArguments(args ast.ArgumentDefinitionList, removed map[string]bool) (ast.ArgumentDefinitionList, bool) {
+	result := ast.ArgumentDefinitionList{}
+	for _, arg := range args {
+		if !removed[arg.Type.Name()] {
+			result = append(result, arg)
+			continue
+		}
+		if arg.Type.NonNull && arg.DefaultValue == nil {
+			return nil, false
+		}
+	}
+	return result, true
+}
+
+func removeNames(names []string, removed map[string]bool) []string {
+	result := []string{}
+	for _, name := range names {
+		if !removed[name] {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+func removeFieldDefinition(fields ast.FieldList, name string) ast.FieldList {
+	result := ast.FieldList{}
+	for _, field := range fields {
+		if field.Name != name {
+			result = append(result, field)
+		}
+	}
+	return result
+}
+
+// copySchema returns a copy of the schema whose user-defined types and directives can be changed without
+// touching the original. Built-in definitions are shared.
+func copySchema(schema *ast.Schema) *ast.Schema {
+	copied := &ast.Schema{
+		Types:       map[string]*ast.Definition{},
+		Directives:  map[string]*ast.DirectiveDefinition{},
+		Description: schema.Description,
+		Comment:     schema.Comment,
+	}
+	for name, definition := range schema.Types {
+		copied.Types[name] = copyDefinition(definition)
+	}
+	for name, directive := range schema.Directives {
+		if isBuiltInDirective(directive) {
+			copied.Directives[name] = directive
+			continue
+		}
+		copiedDirective := *directive
+		copiedDirective.Arguments = copyArgumentDefinitions(directive.Arguments)
+		copiedDirective.Locations = append([]ast.DirectiveLocation{}, directive.Locations...)
+		copied.Directives[name] = &copiedDirective
+	}
+	linkTransformedSchema(schema, copied, keepName)
+	return copied
+}
+
+func copyDefinition(definition *ast.Definition) *ast.Definition {
+	if definition.BuiltIn {
+		return definition
+	}
+
+	copied := *definition
+	copied.Fields = ast.FieldList{}
+	for _, field := range definition.Fields {
+		copiedField := *field
+		copiedField.Type = copyType(field.Type)
+		copiedField.Arguments = copyArgumentDefinitions(field.Arguments)
+		copied.Fields = append(copied.Fields, &copiedField)
+	}
+	copied.EnumValues = append(ast.EnumValueList{}, definition.EnumValues...)
+	copied.Interfaces = append([]string{}, definition.Interfaces...)
+	copied.Types = append([]string{}, definition.Types...)
+	return &copied
+}
+
+func copyArgumentDefinitions(args ast.ArgumentDefinitionList) ast.ArgumentDefinitionList {
+	copied := ast.ArgumentDefinitionList{}
+	for _, arg := range args {
+		copiedArg := *arg
+		copiedArg.Type = copyType(arg.Type)
+		copied = append(copied, &copiedArg)
+	}
+	return copied
+}
+
+func copyType(typ *ast.Type) *ast.Type {
+	if typ == nil {
+		return nil
+	}
+	copied := *typ
+	copied.Elem = copyType(typ.Elem)
+	return &copied
+}
+
+// linkTransformedSchema points the root types of the transformed schema at its own definitions, following
+// any renames, and rebuilds the possible types of its abstract types
+func linkTransformedSchema(original *ast.Schema, transformed *ast.Schema, rename func(string) string) {
+	root := func(definition *ast.Definition) *ast.Definition {
+		if definition == nil {
+			return nil
+		}
+		return transformed.Types[rename(definition.Name)]
+	}
+	transformed.Query = root(original.Query)
+	transformed.Mutation = root(original.Mutation)
+	transformed.Subscription = root(original.Subscription)
+
+	transformed.PossibleTypes = map[string][]*ast.Definition{}
+	transformed.Implements = map[string][]*ast.Definition{}
+	schemaLinkPossibleTypes(transformed, func(*ast.Definition, error) {})
+}
+
+// keepName is the rename function of transforms that don't rename anything
+func keepName(name string) string {
+	return name
+}
+
+// copyQueryDocument returns a copy of the document with the type names in fragments and variables renamed
+func copyQueryDocument(document *ast.QueryDocument, rename func(string) string) *ast.QueryDocument {
+	copied := &ast.QueryDocument{
+		Position: document.Position,
+		Comment:  document.Comment,
+	}
+	for _, operation := range document.Operations {
+		copiedOperation := *operation
+		copiedOperation.VariableDefinitions = ast.VariableDefinitionList{}
+		for _, variable := range operation.VariableDefinitions {
+			copiedVariable := *variable
+			copiedVariable.Type = copyType(variable.Type)
+			renameType(copiedVariable.Type, rename)
+			copiedOperation.VariableDefinitions = append(copiedOperation.VariableDefinitions, &copiedVariable)
+		}
+		copiedOperation.SelectionSet = copySelectionSet(operation.SelectionSet, rename)
+		copied.Operations = append(copied.Operations, &copiedOperation)
+	}
+	for _, fragment := range document.Fragments {
+		copiedFragment := *fragment
+		copiedFragment.TypeCondition = rename(fragment.TypeCondition)
+		copiedFragment.SelectionSet = copySelectionSet(fragment.SelectionSet, rename)
+		copied.Fragments = append(copied.Fragments, &copiedFragment)
+	}
+	return copied
+}
+
+func copySelectionSet(selectionSet ast.SelectionSet, rename func(string) string) ast.SelectionSet {
+	if selectionSet == nil {
+		return nil
+	}
+
+	copied := ast.SelectionSet{}
+	for _, selection := range selectionSet {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			copiedField := *selection
+			copiedField.SelectionSet = copySelectionSet(selection.SelectionSet, rename)
+			copied = append(copied, &copiedField)
+		case *ast.InlineFragment:
+			copiedFragment := *selection
+			if selection.TypeCondition != "" {
+				copiedFragment.TypeCondition = rename(selection.TypeCondition)
+			}
+			copiedFragment.SelectionSet = copySelectionSet(selection.SelectionSet, rename)
+			copied = append(copied, &copiedFragment)
+		case *ast.FragmentSpread:
+			copiedSpread := *selection
+			copied = append(copied, &copiedSpread)
+		}
+	}
+	return copied
+}
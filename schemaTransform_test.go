@@ -0,0 +1,232 @@
+package graphql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+const schemaTransformTestSchema = `
+	type Query {
+		node(id: ID!): Node
+		users(filter: UserFilter): [User!]!
+		search(term: String!): [SearchResult!]!
+	}
+
+	interface Node {
+		id: ID!
+	}
+
+	type User implements Node {
+		id: ID!
+		name: String
+		password: String
+		audit: AuditLog
+	}
+
+	type Post implements Node {
+		id: ID!
+		title: String
+	}
+
+	union SearchResult = User | Post
+
+	input UserFilter {
+		name: String
+		audit: AuditFilter
+	}
+
+	type AuditLog {
+		entries: [String!]!
+	}
+
+	input AuditFilter {
+		after: String
+	}
+
+	type Orphan {
+		id: ID!
+	}
+`
+
+func transformTestSchema(t *testing.T, transforms ...SchemaTransform) (*ast.Schema, *TransformedSchema) {
+	t.Helper()
+	schema, err := LoadSchema(schemaTransformTestSchema)
+	require.NoError(t, err)
+	transformed, err := TransformSchema(schema, transforms...)
+	require.NoError(t, err)
+	return schema, transformed
+}
+
+func TestTransformSchema_renameType(t *testing.T) {
+	t.Parallel()
+	original, transformed := transformTestSchema(t, RenameType("User", "Account"))
+	schema := transformed.Schema
+
+	assert.Nil(t, schema.Types["User"])
+	require.NotNil(t, schema.Types["Account"])
+	assert.Equal(t, "[Account!]!", schema.Query.Fields.ForName("users").Type.String())
+	assert.Equal(t, []string{"Account", "Post"}, schema.Types["SearchResult"].Types)
+	assert.Len(t, schema.GetPossibleTypes(schema.Types["Node"]), 2)
+	assert.NoError(t, ValidateSchema(schema))
+
+	// the original is left alone
+	assert.NotNil(t, original.Types["User"])
+	assert.Equal(t, "[User!]!", original.Query.Fields.ForName("users").Type.String())
+	assert.Same(t, original, transformed.Original)
+}
+
+func TestTransformSchema_prefixTypes(t *testing.T) {
+	t.Parallel()
+	_, transformed := transformTestSchema(t, PrefixTypes("Blog_"))
+	schema := transformed.Schema
+
+	assert.Equal(t, "Query", schema.Query.Name)
+	assert.NotNil(t, schema.Types["Blog_User"])
+	assert.NotNil(t, schema.Types["Blog_UserFilter"])
+	assert.NotNil(t, schema.Types["String"])
+	assert.Equal(t, "Blog_UserFilter", schema.Query.Fields.ForName("users").Arguments.ForName("filter").Type.String())
+	assert.Equal(t, []string{"Blog_Node"}, schema.Types["Blog_Post"].Interfaces)
+	assert.NoError(t, ValidateSchema(schema))
+
+	// queries against the new names are translated back
+	query := `
+		query Search($filter: Blog_UserFilter) {
+			users(filter: $filter) { ...UserFields }
+			search(term: "a") {
+				kind: __typename
+				... on Blog_Post { title }
+			}
+		}
+
+		fragment UserFields on Blog_User {
+			__typename
+			name
+		}
+	`
+	document, err := gqlparser.LoadQuery(schema, query)
+	require.Nil(t, err)
+
+	rewritten, rewriteErr := transformed.RewriteQuery(document)
+	require.NoError(t, rewriteErr)
+	printed, printErr := PrintQuery(rewritten)
+	require.NoError(t, printErr)
+	assert.Equal(t, `query Search ($filter: UserFilter) {
+	users(filter: $filter) {
+		... UserFields
+	}
+	search(term: "a") {
+		kind: __typename
+		... on Post {
+			title
+		}
+	}
+}
+fragment UserFields on User {
+	__typename
+	name
+}
+`, printed)
+	_, gqlErr := gqlparser.LoadQuery(transformed.Original, printed)
+	assert.Nil(t, gqlErr)
+
+	// the caller's document is not changed
+	assert.Equal(t, "Blog_User", document.Fragments[0].TypeCondition)
+
+	// and the type names in the responses are translated forward
+	data, rewriteErr := transformed.RewriteResponse(document, "Search", map[string]interface{}{
+		"users": []interface{}{
+			map[string]interface{}{"__typename": "User", "name": "alice"},
+		},
+		"search": []interface{}{
+			map[string]interface{}{"kind": "User"},
+			map[string]interface{}{"kind": "Post", "title": "hello"},
+		},
+	})
+	require.NoError(t, rewriteErr)
+	assert.Equal(t, map[string]interface{}{
+		"users": []interface{}{
+			map[string]interface{}{"__typename": "Blog_User", "name": "alice"},
+		},
+		"search": []interface{}{
+			map[string]interface{}{"kind": "Blog_User"},
+			map[string]interface{}{"kind": "Blog_Post", "title": "hello"},
+		},
+	}, data)
+}
+
+func TestTransformSchema_removeField(t *testing.T) {
+	t.Parallel()
+	original, transformed := transformTestSchema(t, RemoveField("User", "password"))
+
+	assert.Nil(t, transformed.Schema.Types["User"].Fields.ForName("password"))
+	assert.NotNil(t, transformed.Schema.Types["User"].Fields.ForName("name"))
+	assert.NotNil(t, original.Types["User"].Fields.ForName("password"))
+
+	_, err := TransformSchema(original, RemoveField("User", "missing"))
+	assert.EqualError(t, err, "cannot remove field User.missing: field is not defined")
+}
+
+func TestTransformSchema_removeTypes(t *testing.T) {
+	t.Parallel()
+	original, transformed := transformTestSchema(t, RemoveTypes(func(definition *ast.Definition) bool {
+		return definition.Name == "AuditLog" || definition.Name == "AuditFilter" || definition.Name == "Post"
+	}))
+	schema := transformed.Schema
+
+	assert.Nil(t, schema.Types["AuditLog"])
+	assert.Nil(t, schema.Types["Post"])
+	assert.Nil(t, schema.Types["User"].Fields.ForName("audit"))
+	assert.Nil(t, schema.Types["UserFilter"].Fields.ForName("audit"))
+	assert.Equal(t, []string{"User"}, schema.Types["SearchResult"].Types)
+	assert.Len(t, schema.GetPossibleTypes(schema.Types["Node"]), 1)
+	assert.NoError(t, ValidateSchema(schema))
+
+	// removing the types a root type needs is an error
+	_, err := TransformSchema(original, RemoveTypes(func(definition *ast.Definition) bool {
+		return definition.Kind == ast.Object || definition.Kind == ast.Interface
+	}))
+	assert.EqualError(t, err, "cannot remove root type Query")
+}
+
+func TestTransformSchema_pruneUnreachableTypes(t *testing.T) {
+	t.Parallel()
+	_, transformed := transformTestSchema(t,
+		RemoveField("User", "audit"),
+		PruneUnreachableTypes(),
+	)
+	schema := transformed.Schema
+
+	assert.Nil(t, schema.Types["Orphan"])
+	assert.Nil(t, schema.Types["AuditLog"])
+	// still used by UserFilter
+	assert.NotNil(t, schema.Types["AuditFilter"])
+	assert.NotNil(t, schema.Types["Post"])
+	assert.NotNil(t, schema.Types["String"])
+	assert.NoError(t, ValidateSchema(schema))
+}
+
+func TestTransformSchema_composed(t *testing.T) {
+	t.Parallel()
+	_, transformed := transformTestSchema(t,
+		RenameType("User", "Person"),
+		PrefixTypes("Ext"),
+	)
+	assert.NotNil(t, transformed.Schema.Types["ExtPerson"])
+
+	document, gqlErr := gqlparser.LoadQuery(transformed.Schema, `{ node(id: "1") { __typename ... on ExtPerson { name } } }`)
+	require.Nil(t, gqlErr)
+
+	rewritten, err := transformed.RewriteQuery(document)
+	require.NoError(t, err)
+	assert.Equal(t, "User", rewritten.Operations[0].SelectionSet[0].(*ast.Field).SelectionSet[1].(*ast.InlineFragment).TypeCondition)
+
+	data, err := transformed.RewriteResponse(document, "", map[string]interface{}{
+		"node": map[string]interface{}{"__typename": "User", "name": "bob"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ExtPerson", data["node"].(map[string]interface{})["__typename"])
+}
@@ -0,0 +1,300 @@
+package graphql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// SchemaValidationError describes a schema element that breaks one of the type system rules of the spec
+type SchemaValidationError struct {
+	// Coordinate is the schema coordinate of the invalid element, e.g. User.name(first:) or @include(if:)
+	Coordinate string
+	Message    string
+}
+
+func (e *SchemaValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Coordinate, e.Message)
+}
+
+// IntrospectWithValidation returns an instance of graphql.IntrospectOptions that checks the reconstructed
+// schema with ValidateSchema, so an inconsistent remote schema fails at introspection instead of at query time
+func IntrospectWithValidation() *IntrospectOptions {
+	return introspectOptsFunc(func(opts *IntrospectOptions) {
+		opts.validate = true
+	})
+}
+
+// ValidateSchema checks that a schema, such as one built from an introspection result, follows the type system
+// rules of the spec: every type reference resolves, fields and arguments use the right kind of type, unions only
+// contain objects and types implement every field of their interfaces. Every violation is returned as an
+// ErrorList of *SchemaValidationError. Built-in types and directives are not checked.
+func ValidateSchema(schema *ast.Schema) error {
+	v := &schemaValidator{schema: schema, errs: ErrorList{}}
+
+	v.validateRootTypes()
+	for _, name := range sortedKeys(schema.Types, nil) {
+		if definition := schema.Types[name]; !definition.BuiltIn {
+			v.validateDefinition(definition)
+		}
+	}
+	for _, name := range sortedKeys(schema.Directives, nil) {
+		if directive := schema.Directives[name]; !isBuiltInDirective(directive) {
+			v.validateDirective(directive)
+		}
+	}
+
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+// schemaValidator accumulates the violations found in a schema
+type schemaValidator struct {
+	schema *ast.Schema
+	errs   ErrorList
+}
+
+func (v *schemaValidator) errorf(coordinate string, format string, args ...interface{}) {
+	v.errs = append(v.errs, &SchemaValidationError{
+		Coordinate: coordinate,
+		Message:    fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *schemaValidator) validateRootTypes() {
+	if v.schema.Query == nil {
+		v.errorf("schema", "the query root type is missing")
+	}
+	for _, root := range []struct {
+		operation  string
+		definition *ast.Definition
+	}{
+		{"query", v.schema.Query},
+		{"mutation", v.schema.Mutation},
+		{"subscription", v.schema.Subscription},
+	} {
+		if root.definition != nil && root.definition.Kind != ast.Object {
+			v.errorf(root.definition.Name, "the %s root type must be an object, not %s", root.operation, root.definition.Kind)
+		}
+	}
+}
+
+func (v *schemaValidator) validateDefinition(definition *ast.Definition) {
+	v.validateName(definition.Name, definition.Name)
+
+	switch definition.Kind {
+	case ast.Object, ast.Interface:
+		if len(definition.Fields) == 0 {
+			v.errorf(definition.Name, "%s must define at least one field", strings.ToLower(string(definition.Kind)))
+		}
+		seen := map[string]bool{}
+		for _, field := range definition.Fields {
+			if definition == v.schema.Query && (field.Name == "__schema" || field.Name == "__type") {
+				// the introspection fields added to the query type
+				continue
+			}
+			coordinate := definition.Name + "." + field.Name
+			v.validateName(coordinate, field.Name)
+			if seen[field.Name] {
+				v.errorf(coordinate, "field is defined more than once")
+			}
+			seen[field.Name] = true
+
+			if fieldType := v.validateTypeRef(coordinate, field.Type); fieldType != nil && !isOutputKind(fieldType.Kind) {
+				v.errorf(coordinate, "field type %s must be an output type, not %s", field.Type, fieldType.Kind)
+			}
+			v.validateArguments(coordinate, field.Arguments)
+		}
+		v.validateInterfaces(definition)
+
+	case ast.InputObject:
+		if len(definition.Fields) == 0 {
+			v.errorf(definition.Name, "input object must define at least one field")
+		}
+		seen := map[string]bool{}
+		for _, field := range definition.Fields {
+			coordinate := definition.Name + "." + field.Name
+			v.validateName(coordinate, field.Name)
+			if seen[field.Name] {
+				v.errorf(coordinate, "input field is defined more than once")
+			}
+			seen[field.Name] = true
+
+			if fieldType := v.validateTypeRef(coordinate, field.Type); fieldType != nil && !isInputKind(fieldType.Kind) {
+				v.errorf(coordinate, "input field type %s must be an input type, not %s", field.Type, fieldType.Kind)
+			}
+		}
+
+	case ast.Union:
+		if len(definition.Types) == 0 {
+			v.errorf(definition.Name, "union must have at least one member")
+		}
+		seen := map[string]bool{}
+		for _, name := range definition.Types {
+			if seen[name] {
+				v.errorf(definition.Name, "member %s is included more than once", name)
+			}
+			seen[name] = true
+
+			member, ok := v.schema.Types[name]
+			if !ok {
+				v.errorf(definition.Name, "member %s is not defined", name)
+			} else if member.Kind != ast.Object {
+				v.errorf(definition.Name, "member %s must be an object, not %s", name, member.Kind)
+			}
+		}
+
+	case ast.Enum:
+		if len(definition.EnumValues) == 0 {
+			v.errorf(definition.Name, "enum must define at least one value")
+		}
+		seen := map[string]bool{}
+		for _, value := range definition.EnumValues {
+			coordinate := definition.Name + "." + value.Name
+			v.validateName(coordinate, value.Name)
+			if seen[value.Name] {
+				v.errorf(coordinate, "enum value is defined more than once")
+			}
+			seen[value.Name] = true
+
+			switch value.Name {
+			case "true", "false", "null":
+				v.errorf(coordinate, "enum value cannot be named %s", value.Name)
+			}
+		}
+	}
+}
+
+// validateInterfaces checks that an object or interface implements every interface it claims to
+func (v *schemaValidator) validateInterfaces(definition *ast.Definition) {
+	for _, name := range definition.Interfaces {
+		iface, ok := v.schema.Types[name]
+		if !ok {
+			v.errorf(definition.Name, "implements %s which is not defined", name)
+			continue
+		}
+		if iface.Kind != ast.Interface {
+			v.errorf(definition.Name, "implements %s which is a %s, not an interface", name, iface.Kind)
+			continue
+		}
+		if iface.Name == definition.Name {
+			v.errorf(definition.Name, "cannot implement itself")
+			continue
+		}
+
+		// interfaces implemented by the interface must be declared too
+		for _, inherited := range iface.Interfaces {
+			if inherited != definition.Name && !containsString(definition.Interfaces, inherited) {
+				v.errorf(definition.Name, "must implement %s because %s does", inherited, name)
+			}
+		}
+
+		for _, ifaceField := range iface.Fields {
+			coordinate := definition.Name + "." + ifaceField.Name
+			field := definition.Fields.ForName(ifaceField.Name)
+			if field == nil {
+				v.errorf(definition.Name, "field %s of interface %s is missing", ifaceField.Name, name)
+				continue
+			}
+			if !v.isValidImplementationType(field.Type, ifaceField.Type) {
+				v.errorf(coordinate, "type %s does not match %s.%s of type %s", field.Type, name, ifaceField.Name, ifaceField.Type)
+			}
+
+			for _, ifaceArg := range ifaceField.Arguments {
+				arg := field.Arguments.ForName(ifaceArg.Name)
+				if arg == nil {
+					v.errorf(coordinate, "argument %s of %s.%s is missing", ifaceArg.Name, name, ifaceField.Name)
+				} else if arg.Type.String() != ifaceArg.Type.String() {
+					v.errorf(fmt.Sprintf("%s(%s:)", coordinate, arg.Name), "type %s does not match %s.%s(%s:) of type %s", arg.Type, name, ifaceField.Name, arg.Name, ifaceArg.Type)
+				}
+			}
+			for _, arg := range field.Arguments {
+				if ifaceField.Arguments.ForName(arg.Name) == nil && arg.Type.NonNull && arg.DefaultValue == nil {
+					v.errorf(fmt.Sprintf("%s(%s:)", coordinate, arg.Name), "argument must be optional because %s.%s does not define it", name, ifaceField.Name)
+				}
+			}
+		}
+	}
+}
+
+// isValidImplementationType returns true if a field of the given type can implement an interface field
+// of the interface type, i.e. it is the same or a more specific type
+func (v *schemaValidator) isValidImplementationType(fieldType, ifaceType *ast.Type) bool {
+	if fieldType.NonNull && !ifaceType.NonNull {
+		return v.isValidImplementationType(nullableType(fieldType), ifaceType)
+	}
+	if fieldType.NonNull != ifaceType.NonNull {
+		return false
+	}
+	if fieldType.Elem != nil || ifaceType.Elem != nil {
+		return fieldType.Elem != nil && ifaceType.Elem != nil && v.isValidImplementationType(fieldType.Elem, ifaceType.Elem)
+	}
+	if fieldType.NamedType == ifaceType.NamedType {
+		return true
+	}
+
+	// an object can stand in for an abstract type it belongs to
+	for _, possibleType := range v.schema.PossibleTypes[ifaceType.NamedType] {
+		if possibleType.Name == fieldType.NamedType {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *schemaValidator) validateArguments(parent string, args ast.ArgumentDefinitionList) {
+	seen := map[string]bool{}
+	for _, arg := range args {
+		coordinate := fmt.Sprintf("%s(%s:)", parent, arg.Name)
+		v.validateName(coordinate, arg.Name)
+		if seen[arg.Name] {
+			v.errorf(coordinate, "argument is defined more than once")
+		}
+		seen[arg.Name] = true
+
+		if argType := v.validateTypeRef(coordinate, arg.Type); argType != nil && !isInputKind(argType.Kind) {
+			v.errorf(coordinate, "argument type %s must be an input type, not %s", arg.Type, argType.Kind)
+		}
+	}
+}
+
+func (v *schemaValidator) validateDirective(directive *ast.DirectiveDefinition) {
+	coordinate := "@" + directive.Name
+	v.validateName(coordinate, directive.Name)
+	if len(directive.Locations) == 0 {
+		v.errorf(coordinate, "directive must have at least one location")
+	}
+	v.validateArguments(coordinate, directive.Arguments)
+}
+
+// validateTypeRef returns the definition of the named type at the bottom of the reference, or nil if it is missing
+func (v *schemaValidator) validateTypeRef(coordinate string, typ *ast.Type) *ast.Definition {
+	if typ == nil {
+		v.errorf(coordinate, "type is missing")
+		return nil
+	}
+	definition, ok := v.schema.Types[typ.Name()]
+	if !ok {
+		v.errorf(coordinate, "type %s is not defined", typ.Name())
+		return nil
+	}
+	return definition
+}
+
+// validateName checks that user-defined names don't use the prefix reserved for introspection
+func (v *schemaValidator) validateName(coordinate string, name string) {
+	if strings.HasPrefix(name, "__") {
+		v.errorf(coordinate, "name %s must not begin with \"__\", which is reserved for introspection", name)
+	}
+}
+
+func isOutputKind(kind ast.DefinitionKind) bool {
+	return kind == ast.Scalar || kind == ast.Object || kind == ast.Interface || kind == ast.Union || kind == ast.Enum
+}
+
+func isInputKind(kind ast.DefinitionKind) bool {
+	return kind == ast.Scalar || kind == ast.Enum || kind == ast.InputObject
+}
@@ -0,0 +1,196 @@
+package graphql
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+const schemaValidationTestSchema = `
+	type Query {
+		node(id: ID!): Node
+		search(filter: Filter): [SearchResult!]!
+	}
+
+	interface Node {
+		id: ID!
+	}
+
+	interface Named implements Node {
+		id: ID!
+		name(short: Boolean): String
+	}
+
+	type User implements Named & Node {
+		id: ID!
+		name(short: Boolean, locale: String): String!
+		friends: [User!]
+	}
+
+	type Post implements Node {
+		id: ID!
+		author: User
+	}
+
+	union SearchResult = User | Post
+
+	input Filter {
+		kind: Kind = USER
+	}
+
+	enum Kind {
+		USER
+		POST
+	}
+
+	directive @cached(ttl: Int) on FIELD_DEFINITION
+`
+
+func TestValidateSchema_valid(t *testing.T) {
+	t.Parallel()
+	schema, err := LoadSchema(schemaValidationTestSchema)
+	require.NoError(t, err)
+	assert.NoError(t, ValidateSchema(schema))
+
+	// the reconstructed schema is valid too
+	introspected, err := BuildSchemaFromIntrospection(NewIntrospectionQueryResult(schema))
+	require.NoError(t, err)
+	assert.NoError(t, ValidateSchema(introspected))
+}
+
+func TestValidateSchema(t *testing.T) {
+	t.Parallel()
+	for _, row := range []struct {
+		Message  string
+		Break    func(schema *ast.Schema)
+		Expected []SchemaValidationError
+	}{
+		{
+			Message: "dangling type references",
+			Break: func(schema *ast.Schema) {
+				schema.Types["Post"].Fields.ForName("author").Type = ast.NamedType("Author", nil)
+				schema.Query.Fields.ForName("node").Arguments[0].Type = ast.NonNullNamedType("NodeID", nil)
+				schema.Directives["cached"].Arguments[0].Type = ast.NamedType("Duration", nil)
+			},
+			Expected: []SchemaValidationError{
+				{Coordinate: "Post.author", Message: "type Author is not defined"},
+				{Coordinate: "Query.node(id:)", Message: "type NodeID is not defined"},
+				{Coordinate: "@cached(ttl:)", Message: "type Duration is not defined"},
+			},
+		},
+		{
+			Message: "input and output types in the wrong place",
+			Break: func(schema *ast.Schema) {
+				schema.Types["Post"].Fields.ForName("author").Type = ast.NamedType("Filter", nil)
+				schema.Types["Filter"].Fields.ForName("kind").Type = ast.NamedType("User", nil)
+				schema.Query.Fields.ForName("search").Arguments[0].Type = ast.NamedType("SearchResult", nil)
+			},
+			Expected: []SchemaValidationError{
+				{Coordinate: "Filter.kind", Message: "input field type User must be an input type, not OBJECT"},
+				{Coordinate: "Post.author", Message: "field type Filter must be an output type, not INPUT_OBJECT"},
+				{Coordinate: "Query.search(filter:)", Message: "argument type SearchResult must be an input type, not UNION"},
+			},
+		},
+		{
+			Message: "union members",
+			Break: func(schema *ast.Schema) {
+				schema.Types["SearchResult"].Types = []string{"User", "Node", "Comment", "User"}
+			},
+			Expected: []SchemaValidationError{
+				{Coordinate: "SearchResult", Message: "member Node must be an object, not INTERFACE"},
+				{Coordinate: "SearchResult", Message: "member Comment is not defined"},
+				{Coordinate: "SearchResult", Message: "member User is included more than once"},
+			},
+		},
+		{
+			Message: "interface implementations",
+			Break: func(schema *ast.Schema) {
+				user := schema.Types["User"]
+				user.Interfaces = []string{"Named", "Kind"}
+				user.Fields = ast.FieldList{
+					{Name: "id", Type: ast.NamedType("ID", nil)},
+					{Name: "name", Type: ast.NamedType("String", nil), Arguments: ast.ArgumentDefinitionList{
+						{Name: "short", Type: ast.NonNullNamedType("Boolean", nil)},
+						{Name: "locale", Type: ast.NonNullNamedType("String", nil)},
+					}},
+				}
+				schema.Types["Post"].Fields = ast.FieldList{
+					{Name: "author", Type: ast.NamedType("User", nil)},
+				}
+			},
+			Expected: []SchemaValidationError{
+				{Coordinate: "Post", Message: "field id of interface Node is missing"},
+				{Coordinate: "User", Message: "must implement Node because Named does"},
+				{Coordinate: "User.id", Message: "type ID does not match Named.id of type ID!"},
+				{Coordinate: "User.name(short:)", Message: "type Boolean! does not match Named.name(short:) of type Boolean"},
+				{Coordinate: "User.name(locale:)", Message: "argument must be optional because Named.name does not define it"},
+				{Coordinate: "User", Message: "implements Kind which is a ENUM, not an interface"},
+			},
+		},
+		{
+			Message: "empty definitions and reserved names",
+			Break: func(schema *ast.Schema) {
+				schema.Types["Kind"].EnumValues = ast.EnumValueList{}
+				schema.Types["Filter"].Fields = ast.FieldList{}
+				schema.Types["Post"].Fields = append(schema.Types["Post"].Fields, &ast.FieldDefinition{Name: "__secret", Type: ast.NamedType("String", nil)})
+			},
+			Expected: []SchemaValidationError{
+				{Coordinate: "Filter", Message: "input object must define at least one field"},
+				{Coordinate: "Kind", Message: "enum must define at least one value"},
+				{Coordinate: "Post.__secret", Message: "name __secret must not begin with \"__\", which is reserved for introspection"},
+			},
+		},
+		{
+			Message: "root types",
+			Break: func(schema *ast.Schema) {
+				schema.Mutation = schema.Types["Node"]
+			},
+			Expected: []SchemaValidationError{
+				{Coordinate: "Node", Message: "the mutation root type must be an object, not INTERFACE"},
+			},
+		},
+	} {
+		row := row
+		t.Run(row.Message, func(t *testing.T) {
+			t.Parallel()
+			schema, err := LoadSchema(schemaValidationTestSchema)
+			require.NoError(t, err)
+			row.Break(schema)
+
+			err = ValidateSchema(schema)
+			require.Error(t, err)
+			errList, ok := err.(ErrorList)
+			require.True(t, ok)
+
+			actual := []SchemaValidationError{}
+			for _, err := range errList {
+				actual = append(actual, *err.(*SchemaValidationError))
+			}
+			assert.Equal(t, row.Expected, actual)
+		})
+	}
+}
+
+func TestIntrospectAPI_validation(t *testing.T) {
+	t.Parallel()
+	// a remote service that lists an interface as a union member
+	schema, err := LoadSchema(schemaValidationTestSchema)
+	require.NoError(t, err)
+	schema.Types["SearchResult"].Types = []string{"User", "Node"}
+	result, err := json.Marshal(NewIntrospectionQueryResult(schema))
+	require.NoError(t, err)
+	queryer := &mockOperationQueryer{
+		JSONResults: map[string]string{"IntrospectionQuery": string(result)},
+	}
+
+	_, err = IntrospectAPI(queryer)
+	require.NoError(t, err)
+
+	_, err = IntrospectAPI(queryer, IntrospectWithValidation())
+	assert.EqualError(t, err, "invalid schema: SearchResult: member Node must be an object, not INTERFACE")
+	var validationErr *SchemaValidationError
+	assert.ErrorAs(t, err, &validationErr)
+}
@@ -0,0 +1,145 @@
+package graphql
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// SchemaChangeEvent is passed to the subscribers of a SchemaWatcher when the schema at URL changes
+type SchemaChangeEvent struct {
+	URL       string
+	OldSchema *ast.Schema
+	NewSchema *ast.Schema
+}
+
+// SchemaWatcher periodically introspects a set of remote services and notifies its subscribers
+// when one of their schemas changes
+type SchemaWatcher struct {
+	urls     []string
+	interval time.Duration
+	opts     []*IntrospectOptions
+
+	mu            sync.Mutex
+	schemas       map[string]*watchedSchema
+	subscribers   []func(*SchemaChangeEvent)
+	errorHandlers []func(error)
+}
+
+// watchedSchema is the last known schema of a service along with its fingerprint
+type watchedSchema struct {
+	schema      *ast.Schema
+	fingerprint string
+}
+
+// NewSchemaWatcher returns a SchemaWatcher that introspects the given urls every interval.
+// The options are used for every introspection, so retriers and middlewares apply to each poll.
+func NewSchemaWatcher(urls []string, interval time.Duration, opts ...*IntrospectOptions) *SchemaWatcher {
+	return &SchemaWatcher{
+		urls:     urls,
+		interval: interval,
+		opts:     opts,
+		schemas:  map[string]*watchedSchema{},
+	}
+}
+
+// Subscribe registers a function to call whenever one of the watched schemas changes. It is not called
+// for the first schema retrieved from a service. Subscribers are called from the polling goroutine.
+func (w *SchemaWatcher) Subscribe(fn func(event *SchemaChangeEvent)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// OnError registers a function to call with a *RemoteSchemaError whenever a service cannot be introspected.
+// The last known schema of that service is kept.
+func (w *SchemaWatcher) OnError(fn func(err error)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.errorHandlers = append(w.errorHandlers, fn)
+}
+
+// RemoteSchemas returns the last known schema of every service that has been introspected successfully,
+// in the order of the watched urls
+func (w *SchemaWatcher) RemoteSchemas() []*RemoteSchema {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	schemas := []*RemoteSchema{}
+	for _, url := range w.urls {
+		if watched, ok := w.schemas[url]; ok {
+			schemas = append(schemas, &RemoteSchema{URL: url, Schema: watched.schema})
+		}
+	}
+	return schemas
+}
+
+// Run polls the watched services immediately and then every interval until the context is done,
+// at which point it returns the context's error
+func (w *SchemaWatcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		// errors have already been passed to the error handlers
+		_ = w.Poll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll introspects every watched service once and notifies the subscribers of the schemas that changed.
+// Services that could not be introspected are returned as an ErrorList of *RemoteSchemaError.
+func (w *SchemaWatcher) Poll(ctx context.Context) error {
+	opts := append(append([]*IntrospectOptions{}, w.opts...), IntrospectWithContext(ctx))
+	remoteSchemas, err := IntrospectRemoteSchemasConcurrently(w.urls, 0, opts...)
+
+	// a poll that was interrupted by shutting down is not a change, nor an error worth reporting
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	events := []*SchemaChangeEvent{}
+	w.mu.Lock()
+	for _, remoteSchema := range remoteSchemas {
+		fingerprint := SchemaFingerprint(remoteSchema.Schema)
+		previous, seen := w.schemas[remoteSchema.URL]
+		if seen && previous.fingerprint == fingerprint {
+			continue
+		}
+		w.schemas[remoteSchema.URL] = &watchedSchema{schema: remoteSchema.Schema, fingerprint: fingerprint}
+
+		if seen {
+			events = append(events, &SchemaChangeEvent{
+				URL:       remoteSchema.URL,
+				OldSchema: previous.schema,
+				NewSchema: remoteSchema.Schema,
+			})
+		}
+	}
+	subscribers := append([]func(*SchemaChangeEvent){}, w.subscribers...)
+	errorHandlers := append([]func(error){}, w.errorHandlers...)
+	w.mu.Unlock()
+
+	// call the handlers without holding the lock so they can use the watcher
+	if errList, ok := err.(ErrorList); ok {
+		for _, serviceErr := range errList {
+			for _, handler := range errorHandlers {
+				handler(serviceErr)
+			}
+		}
+	}
+	for _, event := range events {
+		for _, subscriber := range subscribers {
+			subscriber(event)
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,182 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// schemaWatcherTestServer answers introspection queries with a schema that can be swapped out
+type schemaWatcherTestServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	schema   *ast.Schema
+	failures int
+	requests int
+}
+
+func newSchemaWatcherTestServer(t *testing.T, schema string) *schemaWatcherTestServer {
+	t.Helper()
+	server := &schemaWatcherTestServer{}
+	server.setSchema(t, schema)
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.mu.Lock()
+		server.requests++
+		schema := server.schema
+		fail := server.failures > 0
+		if fail {
+			server.failures--
+		}
+		server.mu.Unlock()
+
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		var input QueryInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		data, err := ExecuteIntrospectionQuery(schema, &input)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (s *schemaWatcherTestServer) setSchema(t *testing.T, source string) {
+	t.Helper()
+	schema, err := LoadSchema(source)
+	require.NoError(t, err)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.schema = schema
+}
+
+func (s *schemaWatcherTestServer) fail(times int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = times
+}
+
+func TestSchemaWatcher_Poll(t *testing.T) {
+	t.Parallel()
+	server := newSchemaWatcherTestServer(t, `type Query { a: String }`)
+	other := newSchemaWatcherTestServer(t, `type Query { b: String }`)
+
+	watcher := NewSchemaWatcher([]string{server.URL, other.URL}, time.Hour)
+	events := []*SchemaChangeEvent{}
+	watcher.Subscribe(func(event *SchemaChangeEvent) {
+		events = append(events, event)
+	})
+	errs := []error{}
+	watcher.OnError(func(err error) {
+		errs = append(errs, err)
+	})
+	ctx := context.Background()
+
+	// the first schemas are not changes
+	require.NoError(t, watcher.Poll(ctx))
+	assert.Empty(t, events)
+	remoteSchemas := watcher.RemoteSchemas()
+	require.Len(t, remoteSchemas, 2)
+	assert.Equal(t, server.URL, remoteSchemas[0].URL)
+	assert.NotNil(t, remoteSchemas[0].Schema.Query.Fields.ForName("a"))
+	assert.Equal(t, other.URL, remoteSchemas[1].URL)
+
+	// nothing changed
+	require.NoError(t, watcher.Poll(ctx))
+	assert.Empty(t, events)
+
+	// one of the services deploys a new schema
+	server.setSchema(t, `type Query { a: String, c: Int }`)
+	require.NoError(t, watcher.Poll(ctx))
+	require.Len(t, events, 1)
+	assert.Equal(t, server.URL, events[0].URL)
+	assert.Nil(t, events[0].OldSchema.Query.Fields.ForName("c"))
+	assert.NotNil(t, events[0].NewSchema.Query.Fields.ForName("c"))
+	assert.Equal(t, events[0].NewSchema, watcher.RemoteSchemas()[0].Schema)
+
+	// a failing service keeps its last schema, once both the feature detection and the introspection failed
+	server.fail(2)
+	err := watcher.Poll(ctx)
+	require.Error(t, err)
+	require.Len(t, errs, 1)
+	var serviceErr *RemoteSchemaError
+	require.True(t, errors.As(errs[0], &serviceErr))
+	assert.Equal(t, server.URL, serviceErr.URL)
+	assert.Len(t, events, 1)
+	assert.Equal(t, events[0].NewSchema, watcher.RemoteSchemas()[0].Schema)
+
+	// coming back with the same schema is not a change
+	require.NoError(t, watcher.Poll(ctx))
+	assert.Len(t, events, 1)
+}
+
+func TestSchemaWatcher_retrier(t *testing.T) {
+	t.Parallel()
+	server := newSchemaWatcherTestServer(t, `type Query { a: String }`)
+	server.fail(2)
+
+	watcher := NewSchemaWatcher([]string{server.URL}, time.Hour, IntrospectWithRetrier(NewCountRetrier(2)))
+	require.NoError(t, watcher.Poll(context.Background()))
+	assert.Len(t, watcher.RemoteSchemas(), 1)
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	assert.Equal(t, 3, server.requests)
+}
+
+func TestSchemaWatcher_Run(t *testing.T) {
+	t.Parallel()
+	server := newSchemaWatcherTestServer(t, `type Query { a: String }`)
+
+	watcher := NewSchemaWatcher([]string{server.URL}, 10*time.Millisecond)
+	changed := make(chan *SchemaChangeEvent, 1)
+	watcher.Subscribe(func(event *SchemaChangeEvent) {
+		changed <- event
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- watcher.Run(ctx)
+	}()
+
+	// wait for the first schema before changing it
+	require.Eventually(t, func() bool {
+		return len(watcher.RemoteSchemas()) == 1
+	}, time.Second, time.Millisecond)
+	server.setSchema(t, `type Query { a: String, b: String }`)
+
+	select {
+	case event := <-changed:
+		assert.NotNil(t, event.NewSchema.Query.Fields.ForName("b"))
+	case <-time.After(time.Second):
+		t.Fatal("subscriber was not called")
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.Equal(t, context.Canceled, err)
+	case <-time.After(time.Second):
+		t.Fatal("watcher did not stop")
+	}
+}